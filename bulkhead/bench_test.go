@@ -0,0 +1,53 @@
+package bulkhead_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/slok/goresilience/bulkhead"
+)
+
+var allokf = func(_ context.Context) error { return nil }
+
+// BenchmarkBulkhead compares both bulkhead modes, both acquire a permit of the
+// same semaphore (that implements the priorities, the max queued and the shutdown),
+// the difference is the goroutine hop and allocations of the worker pool handoff.
+func BenchmarkBulkhead(b *testing.B) {
+	benchs := []struct {
+		name string
+		cfg  bulkhead.Config
+	}{
+		{
+			name: "Worker pool bulkhead (permit and handoff to a worker).",
+			cfg:  bulkhead.Config{Workers: 10},
+		},
+		{
+			name: "Semaphore bulkhead (permit and execution on the caller goroutine).",
+			cfg:  bulkhead.Config{Workers: 10, Semaphore: true},
+		},
+	}
+
+	for _, bench := range benchs {
+		b.Run(bench.name+" (sequential)", func(b *testing.B) {
+			runner := bulkhead.New(bench.cfg)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for n := 0; n < b.N; n++ {
+				runner.Run(context.TODO(), allokf)
+			}
+		})
+
+		b.Run(bench.name+" (parallel)", func(b *testing.B) {
+			runner := bulkhead.New(bench.cfg)
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					runner.Run(context.TODO(), allokf)
+				}
+			})
+		})
+	}
+}
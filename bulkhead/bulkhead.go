@@ -2,11 +2,16 @@ package bulkhead
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/concurrencylimit"
+	"github.com/slok/goresilience/concurrencylimit/limit"
 	"github.com/slok/goresilience/errors"
 	"github.com/slok/goresilience/metrics"
+	"github.com/slok/goresilience/recovery"
 )
 
 // Config is the configuration of the Bulkhead runner.
@@ -18,9 +23,34 @@ type Config struct {
 	MaxWaitTime time.Duration
 	// StopC is a channel to stop the workers if required usually used for a graceful stop flow.
 	StopC chan (struct{})
+	// RecoverPanics will recover the panics of the executions on the workers and return
+	// them as `errors.PanicError` errors, instead of crashing the program.
+	RecoverPanics bool
+	// MaxQueued is the max number of executions that can be waiting to be executed,
+	// when reached the new executions will be rejected with `errors.ErrRejectedExecution`.
+	// By default there is no limit.
+	MaxQueued int
+	// Semaphore will execute on the caller goroutine instead of on a pool of workers.
+	// Both modes wait for a permit of a semaphore of Workers permits (that implements
+	// the priorities, the max queued executions and the graceful shutdown), the worker
+	// pool mode additionally hands off the execution to a worker, this costs a goroutine
+	// hop and some allocations per execution that the semaphore mode avoids.
+	Semaphore bool
+	// Limiter is the algorithm that will drive the number of workers, if set the
+	// bulkhead will be resized with the limit calculated after measuring each execution
+	// and Workers will be ignored.
+	Limiter limit.Limiter
+	// ExecutionResultPolicy categorizes the result of the executions for the Limiter.
+	// The executions that didn't get to be executed are not measured.
+	// By default every error will count as a failure.
+	ExecutionResultPolicy concurrencylimit.ExecutionResultPolicy
 }
 
 func (c *Config) defaults() {
+	if c.Limiter != nil {
+		c.Workers = c.Limiter.GetLimit()
+	}
+
 	if c.Workers <= 0 {
 		c.Workers = 15
 	}
@@ -29,15 +59,42 @@ func (c *Config) defaults() {
 		c.MaxWaitTime = 0
 	}
 
+	if c.ExecutionResultPolicy == nil {
+		c.ExecutionResultPolicy = concurrencylimit.FailureOnExternalErrorPolicy
+	}
+
+	if c.MaxQueued < 0 {
+		c.MaxQueued = 0
+	}
+
 	if c.StopC == nil {
 		c.StopC = make(chan struct{})
 	}
 }
 
+// Bulkhead is a bulkhead runner that can be shut down gracefully.
+type Bulkhead interface {
+	goresilience.Runner
+	// Shutdown will stop accepting new executions and will wait until the executions
+	// that are running and waiting finish or the context is done, in that case the
+	// executions that are still waiting will return an `errors.ErrShutdown` error
+	// and the context error is returned.
+	Shutdown(ctx context.Context) error
+	// Resize will change the number of workers of the bulkhead at runtime, when reduced
+	// the executions that are running will finish but no new executions will be started
+	// until the running ones are below the new size.
+	Resize(workers int)
+}
+
 type bulkhead struct {
-	cfg    Config
-	runner goresilience.Runner
-	jobC   chan func() // jobC is the channel used to send job to the worker pool.
+	cfg       Config
+	runner    goresilience.Runner
+	sem       *semaphore  // sem controls the executions that can run at the same time.
+	workers   *workerPool // workers is the pool that execute the jobs (nil on semaphore mode).
+	stopC     chan struct{}
+	stopOnce  sync.Once
+	resizeMu  sync.Mutex
+	inflights int32
 }
 
 // New returns a new bulkhead runner.
@@ -48,12 +105,15 @@ type bulkhead struct {
 // the execution block will wait to be picked by the workers and if they
 // have a max wait time, if that time is passed they will be dropped
 // from the execution queue.
-func New(cfg Config) goresilience.Runner {
-	return NewMiddleware(cfg)(nil)
+//
+// The number of workers can be changed at runtime with `Resize` or driven by
+// a `limit.Limiter`.
+func New(cfg Config) Bulkhead {
+	return NewMiddleware(cfg)(nil).(Bulkhead)
 }
 
 // NewMiddleware returns a new middleware for the runner that returns
-//  bulkhead.New.
+// bulkhead.New. The runners returned by the middleware implement Bulkhead.
 func NewMiddleware(cfg Config) goresilience.Middleware {
 	cfg.defaults()
 
@@ -61,59 +121,151 @@ func NewMiddleware(cfg Config) goresilience.Middleware {
 		b := &bulkhead{
 			cfg:    cfg,
 			runner: goresilience.SanitizeRunner(next),
-			jobC:   make(chan func()),
+			sem:    newSemaphore(cfg.Workers, cfg.MaxQueued),
+			stopC:  make(chan struct{}),
+		}
+
+		if cfg.Semaphore {
+			return b
 		}
 
 		// Our workers in background.
-		go b.startWorkerPool()
+		b.workers = newWorkerPool(cfg.StopC, b.stopC)
+		b.workers.setWorkers(cfg.Workers)
 
 		return b
 	}
 }
 
-func (b bulkhead) Run(ctx context.Context, f goresilience.Func) error {
+func (b *bulkhead) Run(ctx context.Context, f goresilience.Func) error {
+	if b.cfg.Limiter == nil {
+		_, err := b.run(ctx, f, time.Time{})
+		return err
+	}
+
+	// Measure the execution to feed the limiter algorithm.
+	start := time.Now()
+	inflights := int(atomic.AddInt32(&b.inflights, 1))
+	queuedDuration, err := b.run(ctx, f, start)
+	atomic.AddInt32(&b.inflights, -1)
+
+	// If not executed there is nothing to measure.
+	if queuedDuration < 0 {
+		return err
+	}
+
+	result := b.cfg.ExecutionResultPolicy(ctx, err)
+	if result == limit.ResultIgnore {
+		return err
+	}
+
+	b.Resize(b.cfg.Limiter.MeasureSample(start, queuedDuration, inflights, result))
+
+	return err
+}
+
+// run will execute the func when allowed, if start time is set it will return the
+// time waiting to be executed, or negative if not executed.
+func (b *bulkhead) run(ctx context.Context, f goresilience.Func, start time.Time) (time.Duration, error) {
 	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
 
+	// Wait until we are allowed to execute.
+	metricsRecorder.IncBulkheadQueued()
+	if err := b.sem.acquire(ctx, b.cfg.MaxWaitTime); err != nil {
+		switch err {
+		case errors.ErrTimeoutWaitingForExecution:
+			metricsRecorder.IncBulkheadTimeout()
+		case errors.ErrContextCanceled:
+			metricsRecorder.IncBulkheadCanceled()
+		}
+		return -1, err
+	}
+	b.recordWorkers(metricsRecorder)
+	defer func() {
+		b.sem.release()
+		b.recordWorkers(metricsRecorder)
+	}()
+
+	var queuedDuration time.Duration
+	if !start.IsZero() {
+		queuedDuration = time.Since(start)
+	}
+
+	// On semaphore mode we execute on the caller goroutine, if not, we hand off
+	// the execution to a worker (a worker will be free because we have a permit).
+	if b.workers == nil {
+		metricsRecorder.IncBulkheadProcessed()
+		return queuedDuration, b.execute(ctx, f)
+	}
+
 	resC := make(chan error, 1) // The result channel.
 	job := func() {
 		metricsRecorder.IncBulkheadProcessed()
-		resC <- b.runner.Run(ctx, f)
+		resC <- b.execute(ctx, f)
 	}
 
-	metricsRecorder.IncBulkheadQueued()
-	if b.cfg.MaxWaitTime == 0 {
-		select {
-		// Send the function to the worker
-		case b.jobC <- job:
-			// Wait for the result on the result channel.
-			return <-resC
-		}
-	} else {
-		select {
-		case <-time.After(b.cfg.MaxWaitTime):
-			metricsRecorder.IncBulkheadTimeout()
-			return errors.ErrTimeoutWaitingForExecution
-		// Send the function to the worker
-		case b.jobC <- job:
-			// Wait for the result on the result channel.
-			return <-resC
+	select {
+	// Send the function to the worker
+	case b.workers.jobC <- job:
+		// Wait for the result on the result channel.
+		return queuedDuration, <-resC
+	case <-ctx.Done():
+		metricsRecorder.IncBulkheadCanceled()
+		return -1, errors.ErrContextCanceled
+	case <-b.cfg.StopC:
+		return -1, errors.ErrShutdown
+	case <-b.stopC:
+		return -1, errors.ErrShutdown
+	}
+}
+
+func (b *bulkhead) Resize(workers int) {
+	if workers <= 0 {
+		return
+	}
+
+	b.resizeMu.Lock()
+	defer b.resizeMu.Unlock()
+
+	// When increasing we need the workers ready before allowing more executions and
+	// when decreasing we need to stop allowing executions before stopping the workers.
+	if b.workers != nil {
+		if size, _ := b.sem.stats(); workers > size {
+			b.workers.setWorkers(workers)
+			b.sem.resize(workers)
+			return
 		}
+		b.sem.resize(workers)
+		b.workers.setWorkers(workers)
+		return
+	}
+
+	b.sem.resize(workers)
+}
+
+// recordWorkers will measure the workers of the bulkhead.
+func (b *bulkhead) recordWorkers(metricsRecorder metrics.Recorder) {
+	size, acquired := b.sem.stats()
+	metricsRecorder.SetBulkheadWorkers(size)
+	metricsRecorder.SetBulkheadActiveWorkers(acquired)
+}
+
+func (b *bulkhead) Shutdown(ctx context.Context) error {
+	defer b.stopOnce.Do(func() { close(b.stopC) })
+
+	select {
+	case <-b.sem.close():
+		return nil
+	case <-ctx.Done():
+		b.sem.failWaiters(errors.ErrShutdown)
+		return ctx.Err()
 	}
 }
 
-// startWorkerPool will start the execution of the worker pool.
-func (b bulkhead) startWorkerPool() {
-	for i := 0; i < b.cfg.Workers; i++ {
-		// Run worker.
-		go func() {
-			for {
-				select {
-				case <-b.cfg.StopC:
-					return
-				case job := <-b.jobC:
-					job()
-				}
-			}
-		}()
+// execute will execute the runner.
+func (b *bulkhead) execute(ctx context.Context, f goresilience.Func) error {
+	if b.cfg.RecoverPanics {
+		return recovery.Call(ctx, func() error { return b.runner.Run(ctx, f) })
 	}
+	return b.runner.Run(ctx, f)
 }
@@ -2,13 +2,19 @@ package bulkhead_test
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/slok/goresilience"
 	"github.com/slok/goresilience/bulkhead"
+	"github.com/slok/goresilience/concurrencylimit/limit"
+	grerrors "github.com/slok/goresilience/errors"
+	mlimit "github.com/slok/goresilience/internal/mocks/concurrencylimit/limit"
 )
 
 func TestBulkheadTimeout(t *testing.T) {
@@ -49,6 +55,38 @@ func TestBulkheadTimeout(t *testing.T) {
 			expTotalCalls: 10,
 			expTotalErrs:  90,
 		},
+		{
+			name: "A semaphore bulkhead without timeout should complete all runs.",
+			cfg: bulkhead.Config{
+				Semaphore: true,
+			},
+			runFunc: func() goresilience.Func {
+				return func(ctx context.Context) error {
+					time.Sleep(2 * time.Millisecond)
+					return nil
+				}
+			},
+			timesToCall:   100,
+			expTotalCalls: 100,
+			expTotalErrs:  0,
+		},
+		{
+			name: "A semaphore bulkhead with timeout should timeout the funcs waiting to run if they have waited too much.",
+			cfg: bulkhead.Config{
+				Workers:     10,
+				MaxWaitTime: 5 * time.Millisecond,
+				Semaphore:   true,
+			},
+			runFunc: func() goresilience.Func {
+				return func(ctx context.Context) error {
+					time.Sleep(20 * time.Millisecond)
+					return nil
+				}
+			},
+			timesToCall:   100,
+			expTotalCalls: 10,
+			expTotalErrs:  90,
+		},
 	}
 
 	for _, test := range tests {
@@ -83,3 +121,400 @@ func TestBulkheadTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkheadRecoverPanics(t *testing.T) {
+	assert := assert.New(t)
+
+	bk := bulkhead.New(bulkhead.Config{
+		RecoverPanics: true,
+	})
+	err := bk.Run(context.TODO(), func(_ context.Context) error {
+		panic("wanted panic")
+	})
+
+	assert.True(errors.Is(err, grerrors.ErrPanic))
+}
+
+func TestBulkheadContextCanceled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  bulkhead.Config
+	}{
+		{
+			name: "A worker pool bulkhead should stop waiting when the context is done.",
+			cfg:  bulkhead.Config{Workers: 1},
+		},
+		{
+			name: "A semaphore bulkhead should stop waiting when the context is done.",
+			cfg:  bulkhead.Config{Workers: 1, Semaphore: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bk := bulkhead.New(test.cfg)
+
+			// Fill the bulkhead.
+			release := make(chan struct{})
+			running := make(chan struct{})
+			go bk.Run(context.TODO(), func(_ context.Context) error {
+				close(running)
+				<-release
+				return nil
+			})
+			<-running
+
+			// The waiting execution should be cancelled and never executed.
+			executed := false
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := bk.Run(ctx, func(_ context.Context) error {
+				executed = true
+				return nil
+			})
+			assert.Equal(grerrors.ErrContextCanceled, err)
+			assert.False(executed)
+
+			// Once released the bulkhead should accept executions again.
+			close(release)
+			assert.NoError(bk.Run(context.TODO(), func(_ context.Context) error { return nil }))
+		})
+	}
+}
+
+// blockingFunc returns a Func that will block until released and a channel
+// that will receive an event when the Func starts executing.
+func blockingFunc(release chan struct{}) (goresilience.Func, chan struct{}) {
+	running := make(chan struct{}, 100)
+	return func(_ context.Context) error {
+		running <- struct{}{}
+		<-release
+		return nil
+	}, running
+}
+
+func TestBulkheadMaxQueued(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  bulkhead.Config
+	}{
+		{
+			name: "A worker pool bulkhead should reject the executions when the queue is full.",
+			cfg:  bulkhead.Config{Workers: 1, MaxQueued: 2},
+		},
+		{
+			name: "A semaphore bulkhead should reject the executions when the queue is full.",
+			cfg:  bulkhead.Config{Workers: 1, MaxQueued: 2, Semaphore: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bk := bulkhead.New(test.cfg)
+			release := make(chan struct{})
+			f, running := blockingFunc(release)
+
+			// Fill the worker and the queue.
+			results := make(chan error, 3)
+			go func() { results <- bk.Run(context.TODO(), f) }()
+			<-running
+			go func() { results <- bk.Run(context.TODO(), f) }()
+			go func() { results <- bk.Run(context.TODO(), f) }()
+			time.Sleep(20 * time.Millisecond)
+
+			// The queue is full.
+			err := bk.Run(context.TODO(), f)
+			assert.Equal(grerrors.ErrRejectedExecution, err)
+
+			// The queued executions should be executed.
+			close(release)
+			for i := 0; i < 3; i++ {
+				assert.NoError(<-results)
+			}
+		})
+	}
+}
+
+func TestBulkheadShutdown(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            bulkhead.Config
+		shutdownWait   time.Duration
+		releaseAfter   time.Duration
+		expShutdownErr error
+		expErrs        []error
+	}{
+		{
+			name:         "A worker pool bulkhead shutdown should wait for the running and queued executions.",
+			cfg:          bulkhead.Config{Workers: 1},
+			shutdownWait: 1 * time.Second,
+			releaseAfter: 20 * time.Millisecond,
+			expErrs:      []error{nil, nil, nil},
+		},
+		{
+			name:         "A semaphore bulkhead shutdown should wait for the running and queued executions.",
+			cfg:          bulkhead.Config{Workers: 1, Semaphore: true},
+			shutdownWait: 1 * time.Second,
+			releaseAfter: 20 * time.Millisecond,
+			expErrs:      []error{nil, nil, nil},
+		},
+		{
+			name:           "A worker pool bulkhead shutdown should fail the queued executions when the context is done.",
+			cfg:            bulkhead.Config{Workers: 1},
+			shutdownWait:   20 * time.Millisecond,
+			releaseAfter:   100 * time.Millisecond,
+			expShutdownErr: context.DeadlineExceeded,
+			expErrs:        []error{nil, grerrors.ErrShutdown, grerrors.ErrShutdown},
+		},
+		{
+			name:           "A semaphore bulkhead shutdown should fail the queued executions when the context is done.",
+			cfg:            bulkhead.Config{Workers: 1, Semaphore: true},
+			shutdownWait:   20 * time.Millisecond,
+			releaseAfter:   100 * time.Millisecond,
+			expShutdownErr: context.DeadlineExceeded,
+			expErrs:        []error{nil, grerrors.ErrShutdown, grerrors.ErrShutdown},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bk := bulkhead.New(test.cfg)
+			release := make(chan struct{})
+			f, running := blockingFunc(release)
+
+			// One running and the others queued.
+			results := make([]chan error, len(test.expErrs))
+			for i := range results {
+				results[i] = make(chan error, 1)
+				resC := results[i]
+				go func() { resC <- bk.Run(context.TODO(), f) }()
+				if i == 0 {
+					<-running
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			go func() {
+				time.Sleep(test.releaseAfter)
+				close(release)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), test.shutdownWait)
+			defer cancel()
+			err := bk.Shutdown(ctx)
+			assert.Equal(test.expShutdownErr, err)
+
+			// After the shutdown no new executions are accepted.
+			assert.Equal(grerrors.ErrShutdown, bk.Run(context.TODO(), f))
+
+			gotErrs := make([]error, len(results))
+			for i, resC := range results {
+				gotErrs[i] = <-resC
+			}
+			assert.Equal(test.expErrs, gotErrs)
+		})
+	}
+}
+
+func TestBulkheadPriority(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         bulkhead.Config
+		queued      []goresilience.Priority
+		expOrder    []int
+		expRejected []int
+	}{
+		{
+			name:     "A worker pool bulkhead should execute first the queued executions with higher priority.",
+			cfg:      bulkhead.Config{Workers: 1},
+			queued:   []goresilience.Priority{goresilience.PrioritySheddable, goresilience.PriorityNormal, goresilience.PriorityCritical, goresilience.PriorityNormal},
+			expOrder: []int{2, 1, 3, 0},
+		},
+		{
+			name:     "A semaphore bulkhead should execute first the queued executions with higher priority.",
+			cfg:      bulkhead.Config{Workers: 1, Semaphore: true},
+			queued:   []goresilience.Priority{goresilience.PrioritySheddable, goresilience.PriorityNormal, goresilience.PriorityCritical, goresilience.PriorityNormal},
+			expOrder: []int{2, 1, 3, 0},
+		},
+		{
+			name:        "A worker pool bulkhead with the queue full should reject first the executions with lower priority.",
+			cfg:         bulkhead.Config{Workers: 1, MaxQueued: 2},
+			queued:      []goresilience.Priority{goresilience.PriorityNormal, goresilience.PriorityNormal, goresilience.PriorityCritical, goresilience.PrioritySheddable},
+			expOrder:    []int{2, 0},
+			expRejected: []int{1, 3},
+		},
+		{
+			name:        "A semaphore bulkhead with the queue full should reject first the executions with lower priority.",
+			cfg:         bulkhead.Config{Workers: 1, MaxQueued: 2, Semaphore: true},
+			queued:      []goresilience.Priority{goresilience.PriorityNormal, goresilience.PriorityNormal, goresilience.PriorityCritical, goresilience.PrioritySheddable},
+			expOrder:    []int{2, 0},
+			expRejected: []int{1, 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bk := bulkhead.New(test.cfg)
+
+			// Block the bulkhead.
+			release := make(chan struct{})
+			f, running := blockingFunc(release)
+			go bk.Run(context.TODO(), f)
+			<-running
+
+			// Queue the executions in order.
+			var mu sync.Mutex
+			var gotOrder, gotRejected []int
+			var wg sync.WaitGroup
+			for i, p := range test.queued {
+				i := i
+				ctx := goresilience.SetPriorityOnContext(context.TODO(), p)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := bk.Run(ctx, func(_ context.Context) error {
+						mu.Lock()
+						gotOrder = append(gotOrder, i)
+						mu.Unlock()
+						return nil
+					})
+					if err == grerrors.ErrRejectedExecution {
+						mu.Lock()
+						gotRejected = append(gotRejected, i)
+						mu.Unlock()
+					}
+				}()
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			close(release)
+			wg.Wait()
+
+			assert.Equal(test.expOrder, gotOrder)
+			assert.Equal(test.expRejected, gotRejected)
+		})
+	}
+}
+
+func TestBulkheadResize(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            func() bulkhead.Config
+		resize         func(bk bulkhead.Bulkhead)
+		expConcurrency int
+	}{
+		{
+			name: "A worker pool bulkhead should increase the concurrent executions when resized.",
+			cfg:  func() bulkhead.Config { return bulkhead.Config{Workers: 1} },
+			resize: func(bk bulkhead.Bulkhead) {
+				bk.Resize(3)
+			},
+			expConcurrency: 3,
+		},
+		{
+			name: "A semaphore bulkhead should increase the concurrent executions when resized.",
+			cfg:  func() bulkhead.Config { return bulkhead.Config{Workers: 1, Semaphore: true} },
+			resize: func(bk bulkhead.Bulkhead) {
+				bk.Resize(3)
+			},
+			expConcurrency: 3,
+		},
+		{
+			name: "A worker pool bulkhead should decrease the concurrent executions when resized.",
+			cfg:  func() bulkhead.Config { return bulkhead.Config{Workers: 4} },
+			resize: func(bk bulkhead.Bulkhead) {
+				bk.Resize(2)
+			},
+			expConcurrency: 2,
+		},
+		{
+			name: "A semaphore bulkhead should decrease the concurrent executions when resized.",
+			cfg:  func() bulkhead.Config { return bulkhead.Config{Workers: 4, Semaphore: true} },
+			resize: func(bk bulkhead.Bulkhead) {
+				bk.Resize(2)
+			},
+			expConcurrency: 2,
+		},
+		{
+			name: "A worker pool bulkhead resized while executing should decrease the concurrent executions after the running ones finish.",
+			cfg:  func() bulkhead.Config { return bulkhead.Config{Workers: 3} },
+			resize: func(bk bulkhead.Bulkhead) {
+				release := make(chan struct{})
+				f, running := blockingFunc(release)
+				var wg sync.WaitGroup
+				for i := 0; i < 3; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						bk.Run(context.TODO(), f)
+					}()
+					<-running
+				}
+				bk.Resize(1)
+				close(release)
+				wg.Wait()
+			},
+			expConcurrency: 1,
+		},
+		{
+			name: "A worker pool bulkhead with a limiter should be resized by the limiter.",
+			cfg: func() bulkhead.Config {
+				ml := &mlimit.Limiter{}
+				ml.On("GetLimit").Return(1)
+				ml.On("MeasureSample", mock.Anything, mock.Anything, mock.Anything, limit.ResultSuccess).Return(3)
+				return bulkhead.Config{Limiter: ml}
+			},
+			resize: func(bk bulkhead.Bulkhead) {
+				bk.Run(context.TODO(), func(_ context.Context) error { return nil })
+			},
+			expConcurrency: 3,
+		},
+		{
+			name: "A semaphore bulkhead with a limiter should be resized by the limiter.",
+			cfg: func() bulkhead.Config {
+				ml := &mlimit.Limiter{}
+				ml.On("GetLimit").Return(1)
+				ml.On("MeasureSample", mock.Anything, mock.Anything, mock.Anything, limit.ResultSuccess).Return(3)
+				return bulkhead.Config{Limiter: ml, Semaphore: true}
+			},
+			resize: func(bk bulkhead.Bulkhead) {
+				bk.Run(context.TODO(), func(_ context.Context) error { return nil })
+			},
+			expConcurrency: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bk := bulkhead.New(test.cfg())
+			test.resize(bk)
+
+			// Execute more than the allowed and check the concurrent ones.
+			release := make(chan struct{})
+			f, running := blockingFunc(release)
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					bk.Run(context.TODO(), f)
+				}()
+			}
+			time.Sleep(20 * time.Millisecond)
+			assert.Equal(test.expConcurrency, len(running))
+
+			close(release)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package bulkhead
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/errors"
+)
+
+// waiter is a caller waiting for a permit of the semaphore.
+type waiter struct {
+	priority goresilience.Priority
+	// ready will be closed when the waiter has finished waiting.
+	ready chan struct{}
+	// err is the error of the wait, nil if the permit was acquired.
+	err error
+}
+
+// semaphore is a semaphore of 1 weight permits that knows how to wait for a
+// permit in FIFO order (by priority) with a max wait time and the context
+// cancellation. It also knows how to limit the waiters (rejecting first the
+// ones with lower priority) and to be closed gracefully.
+type semaphore struct {
+	size      int
+	maxQueued int
+	acquired  int
+	waiters   list.List
+	closed    bool
+	drained   bool
+	drainedC  chan struct{} // drainedC will be closed when the semaphore is closed and has no permits acquired nor waiters.
+	mu        sync.Mutex
+}
+
+func newSemaphore(size, maxQueued int) *semaphore {
+	return &semaphore{
+		size:      size,
+		maxQueued: maxQueued,
+		drainedC:  make(chan struct{}),
+	}
+}
+
+// acquire will acquire a permit, if there aren't permits available it will
+// wait until one is released, the max wait time is reached (0 waits forever)
+// or the context is done.
+func (s *semaphore) acquire(ctx context.Context, maxWait time.Duration) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.ErrShutdown
+	}
+
+	if s.acquired < s.size && s.waiters.Len() == 0 {
+		s.acquired++
+		s.mu.Unlock()
+		return nil
+	}
+
+	priority := goresilience.PriorityFromContext(ctx)
+	if s.maxQueued > 0 && s.waiters.Len() >= s.maxQueued {
+		// If the queue is full the lowest priority waiter will be rejected,
+		// the last one has the lowest priority.
+		last := s.waiters.Back()
+		lw := last.Value.(*waiter)
+		if lw.priority >= priority {
+			s.mu.Unlock()
+			return errors.ErrRejectedExecution
+		}
+		s.waiters.Remove(last)
+		lw.err = errors.ErrRejectedExecution
+		close(lw.ready)
+	}
+
+	w := &waiter{priority: priority, ready: make(chan struct{})}
+	elem := s.enqueue(w)
+	s.mu.Unlock()
+
+	var timeoutC <-chan time.Time
+	if maxWait > 0 {
+		t := time.NewTimer(maxWait)
+		defer t.Stop()
+		timeoutC = t.C
+	}
+
+	var err error
+	select {
+	case <-w.ready:
+		return w.err
+	case <-ctx.Done():
+		err = errors.ErrContextCanceled
+	case <-timeoutC:
+		err = errors.ErrTimeoutWaitingForExecution
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-w.ready:
+		// We finished waiting while we were giving up, so ignore
+		// that we gave up.
+		return w.err
+	default:
+		s.waiters.Remove(elem)
+		s.checkDrained()
+		return err
+	}
+}
+
+// enqueue will queue the waiter after the waiters with the same or greater priority.
+func (s *semaphore) enqueue(w *waiter) *list.Element {
+	for e := s.waiters.Back(); e != nil; e = e.Prev() {
+		if e.Value.(*waiter).priority >= w.priority {
+			return s.waiters.InsertAfter(w, e)
+		}
+	}
+	return s.waiters.PushFront(w)
+}
+
+// release will release a permit.
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.acquired--
+	s.notifyWaiters()
+	s.checkDrained()
+}
+
+// resize will change the number of permits of the semaphore, when reduced the
+// acquired permits will not be available again until the acquired ones are
+// below the new size.
+func (s *semaphore) resize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.size = size
+	s.notifyWaiters()
+}
+
+// stats returns the number of permits of the semaphore and the acquired ones.
+func (s *semaphore) stats() (size, acquired int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.size, s.acquired
+}
+
+// close will stop accepting new waiters, the current ones will continue
+// waiting for the permits. It returns a channel that will be closed when
+// there are no permits acquired nor waiters.
+func (s *semaphore) close() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.checkDrained()
+	return s.drainedC
+}
+
+// failWaiters will end the wait of all the waiters with an error.
+func (s *semaphore) failWaiters(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for e := s.waiters.Front(); e != nil; e = s.waiters.Front() {
+		w := s.waiters.Remove(e).(*waiter)
+		w.err = err
+		close(w.ready)
+	}
+	s.checkDrained()
+}
+
+// notifyWaiters will give the available permits to the waiters in order.
+func (s *semaphore) notifyWaiters() {
+	for s.acquired < s.size {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+
+		s.acquired++
+		close(s.waiters.Remove(next).(*waiter).ready)
+	}
+}
+
+// checkDrained will mark the semaphore as drained if it's closed and doesn't
+// have permits acquired nor waiters.
+func (s *semaphore) checkDrained() {
+	if s.closed && !s.drained && s.acquired == 0 && s.waiters.Len() == 0 {
+		s.drained = true
+		close(s.drainedC)
+	}
+}
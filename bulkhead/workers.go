@@ -0,0 +1,58 @@
+package bulkhead
+
+import (
+	"sync"
+)
+
+// workerPool knows how to increase and decrease the workers that execute
+// the jobs of the bulkhead.
+type workerPool struct {
+	jobC           chan func()
+	workerStoppers []chan struct{}
+	stopC          chan struct{} // stopC will stop all the workers.
+	shutdownC      chan struct{} // shutdownC will stop all the workers when the bulkhead is shut down.
+	mu             sync.Mutex
+}
+
+func newWorkerPool(stopC, shutdownC chan struct{}) *workerPool {
+	return &workerPool{
+		jobC:      make(chan func()),
+		stopC:     stopC,
+		shutdownC: shutdownC,
+	}
+}
+
+// setWorkers knows how to increase or decrease the worker pool, the stopped
+// workers will finish the job they are executing before stopping.
+func (w *workerPool) setWorkers(quantity int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Add the missing workers.
+	for len(w.workerStoppers) < quantity {
+		workerStopC := make(chan struct{})
+		go w.worker(workerStopC)
+		w.workerStoppers = append(w.workerStoppers, workerStopC)
+	}
+
+	// Stop the not needed workers.
+	for len(w.workerStoppers) > quantity {
+		close(w.workerStoppers[0])
+		w.workerStoppers = w.workerStoppers[1:]
+	}
+}
+
+func (w *workerPool) worker(workerStopC chan struct{}) {
+	for {
+		select {
+		case <-workerStopC:
+			return
+		case <-w.stopC:
+			return
+		case <-w.shutdownC:
+			return
+		case job := <-w.jobC:
+			job()
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package coalesce
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/metrics"
+)
+
+var ctxKeyKey contextKey = "key"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "coalesce-ctx-key" + string(c)
+}
+
+// KeyFromContext will get the coalescing key of the execution from the context.
+func KeyFromContext(ctx context.Context) (key string, ok bool) {
+	key, ok = ctx.Value(ctxKeyKey).(string)
+	return key, ok
+}
+
+// SetKeyOnContext will set the coalescing key of the execution on the context.
+func SetKeyOnContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, ctxKeyKey, key)
+}
+
+// Config is the configuration of the coalesce runner.
+type Config struct {
+	// Key is the function used to get the key of the execution, the executions
+	// with the same key will be coalesced. If it returns false the execution
+	// will not be coalesced. By default it will get the key from the context
+	// (set using `SetKeyOnContext`).
+	Key func(ctx context.Context) (key string, ok bool)
+}
+
+func (c *Config) defaults() {
+	if c.Key == nil {
+		c.Key = KeyFromContext
+	}
+}
+
+// NewOf returns a new typed coalesce runner.
+//
+// The coalesce runner will deduplicate the concurrent executions that share
+// the same key, only one of them will be executed and the result will be
+// returned to all of them (also known as singleflight).
+//
+// The shared execution is not bound to the cancellation of the caller that
+// started it, every caller can stop waiting when its context is done and the
+// shared execution context will be cancelled only when all the callers have
+// stopped waiting.
+//
+// Note: There is no untyped version because only the `goresilience.FuncOf` of the
+// first caller is executed, the result is the only way the other callers have to
+// get the data of the shared execution.
+func NewOf[T any](cfg Config) goresilience.RunnerOf[T] {
+	return NewMiddlewareOf[T](cfg)(nil)
+}
+
+// NewMiddlewareOf returns a typed middleware that uses the Runner returned
+// by coalesce.NewOf.
+func NewMiddlewareOf[T any](cfg Config) goresilience.MiddlewareOf[T] {
+	cfg.defaults()
+
+	return func(next goresilience.RunnerOf[T]) goresilience.RunnerOf[T] {
+		next = goresilience.SanitizeRunnerOf(next)
+		g := newGroup[T]()
+		return goresilience.RunnerOfFunc[T](func(ctx context.Context, f goresilience.FuncOf[T]) (T, error) {
+			key, ok := cfg.Key(ctx)
+			if !ok {
+				return next.Run(ctx, f)
+			}
+
+			return g.do(ctx, key, func(ctx context.Context) (T, error) {
+				return next.Run(ctx, f)
+			})
+		})
+	}
+}
+
+// call is an in flight shared execution.
+type call[T any] struct {
+	done    chan struct{}
+	res     T
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// group knows how to share the executions by key.
+type group[T any] struct {
+	calls map[string]*call[T]
+	mu    sync.Mutex
+}
+
+func newGroup[T any]() *group[T] {
+	return &group[T]{
+		calls: map[string]*call[T]{},
+	}
+}
+
+func (g *group[T]) do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	g.mu.Lock()
+	c, ok := g.calls[key]
+	if ok {
+		c.waiters++
+		g.mu.Unlock()
+		metricsRecorder.IncCoalesceExecution(true)
+	} else {
+		// The shared execution doesn't depend on the cancellation of the caller.
+		sharedCtx, cancel := context.WithCancel(detachedContext{parent: ctx})
+		c = &call[T]{
+			done:    make(chan struct{}),
+			waiters: 1,
+			cancel:  cancel,
+		}
+		g.calls[key] = c
+		g.mu.Unlock()
+		metricsRecorder.IncCoalesceExecution(false)
+
+		go func() {
+			c.res, c.err = fn(sharedCtx)
+			g.forget(key, c)
+			cancel()
+			close(c.done)
+		}()
+	}
+
+	select {
+	case <-c.done:
+		return c.res, c.err
+	case <-ctx.Done():
+		// If we were the last waiting, nobody needs the execution.
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+
+		var zero T
+		return zero, errors.ErrContextCanceled
+	}
+}
+
+// forget will remove the call from the in flight calls.
+func (g *group[T]) forget(key string, c *call[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
+
+// detachedContext is a context that has the values of the parent context
+// but not the cancellation nor the deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (d detachedContext) Value(key interface{}) interface{} { return d.parent.Value(key) }
@@ -0,0 +1,146 @@
+package coalesce_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience/coalesce"
+	grerrors "github.com/slok/goresilience/errors"
+)
+
+func TestCoalesce(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		expExecutions int
+	}{
+		{
+			name:          "Concurrent executions with the same key should be executed once.",
+			keys:          []string{"a", "a", "a", "a", "a"},
+			expExecutions: 1,
+		},
+		{
+			name:          "Concurrent executions with different keys should be executed once per key.",
+			keys:          []string{"a", "b", "a", "b", "c"},
+			expExecutions: 3,
+		},
+		{
+			name:          "Executions without key should not be coalesced.",
+			keys:          []string{"", "", "a", "a"},
+			expExecutions: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := coalesce.NewOf[string](coalesce.Config{})
+
+			var mu sync.Mutex
+			executions := 0
+			release := make(chan struct{})
+
+			type result struct {
+				key string
+				res string
+			}
+			results := make(chan result)
+			for _, key := range test.keys {
+				key := key
+				go func() {
+					ctx := context.TODO()
+					if key != "" {
+						ctx = coalesce.SetKeyOnContext(ctx, key)
+					}
+
+					res, _ := runner.Run(ctx, func(_ context.Context) (string, error) {
+						mu.Lock()
+						executions++
+						mu.Unlock()
+						<-release
+						return "result-" + key, nil
+					})
+					results <- result{key: key, res: res}
+				}()
+			}
+
+			// Wait until all the executions are in flight.
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+
+			for range test.keys {
+				r := <-results
+				assert.Equal("result-"+r.key, r.res)
+			}
+			assert.Equal(test.expExecutions, executions)
+		})
+	}
+}
+
+func TestCoalesceCancel(t *testing.T) {
+	tests := []struct {
+		name              string
+		cancelAll         bool
+		expSharedCanceled bool
+	}{
+		{
+			name:              "If a caller cancels the shared execution should continue for the other callers.",
+			cancelAll:         false,
+			expSharedCanceled: false,
+		},
+		{
+			name:              "If all the callers cancel the shared execution should be cancelled.",
+			cancelAll:         true,
+			expSharedCanceled: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := coalesce.NewOf[struct{}](coalesce.Config{})
+			sharedCanceled := make(chan bool, 1)
+			f := func(ctx context.Context) (struct{}, error) {
+				select {
+				case <-ctx.Done():
+					sharedCanceled <- true
+				case <-time.After(50 * time.Millisecond):
+					sharedCanceled <- false
+				}
+				return struct{}{}, nil
+			}
+
+			ctx1, cancel1 := context.WithCancel(coalesce.SetKeyOnContext(context.TODO(), "a"))
+			ctx2, cancel2 := context.WithCancel(coalesce.SetKeyOnContext(context.TODO(), "a"))
+			defer cancel2()
+
+			errs := make(chan error, 2)
+			run := func(ctx context.Context) {
+				_, err := runner.Run(ctx, f)
+				errs <- err
+			}
+			go run(ctx1)
+			time.Sleep(5 * time.Millisecond)
+			go run(ctx2)
+			time.Sleep(5 * time.Millisecond)
+
+			cancel1()
+			assert.Equal(grerrors.ErrContextCanceled, <-errs)
+
+			if test.cancelAll {
+				cancel2()
+				assert.Equal(grerrors.ErrContextCanceled, <-errs)
+			} else {
+				assert.NoError(<-errs)
+			}
+
+			assert.Equal(test.expSharedCanceled, <-sharedCanceled)
+		})
+	}
+}
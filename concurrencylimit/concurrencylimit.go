@@ -3,11 +3,13 @@ package concurrencylimit
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/slok/goresilience"
 	"github.com/slok/goresilience/concurrencylimit/execute"
 	"github.com/slok/goresilience/concurrencylimit/limit"
+	"github.com/slok/goresilience/errors"
 	"github.com/slok/goresilience/metrics"
 )
 
@@ -79,8 +81,10 @@ func (c *concurrencylimit) Run(ctx context.Context, f goresilience.Func) error {
 	metricsRecorder.SetConcurrencyLimitInflightExecutions(currentInflights)
 
 	var queuedDuration time.Duration // The time in queue.
+	var executed int32
 	err := c.cfg.Executor.Execute(ctx, func() error {
 		// At this point we are being executed, this means we have been dequeued.
+		atomic.StoreInt32(&executed, 1)
 		queuedDuration = time.Since(start)
 		metricsRecorder.ObserveConcurrencyLimitQueuedTime(start)
 		executing := c.executing.Inc()
@@ -97,6 +101,11 @@ func (c *concurrencylimit) Run(ctx context.Context, f goresilience.Func) error {
 	currentInflights = c.inflights.Dec()
 	metricsRecorder.SetConcurrencyLimitInflightExecutions(currentInflights)
 
+	// Measure the executions that stopped waiting on the queue.
+	if err == errors.ErrContextCanceled && atomic.LoadInt32(&executed) == 0 {
+		metricsRecorder.IncConcurrencyLimitCanceled()
+	}
+
 	// Measure to feed the algorithm.
 	result := c.cfg.ExecutionResultPolicy(ctx, err)
 	metricsRecorder.IncConcurrencyLimitResult(string(result))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/slok/goresilience"
 	"github.com/slok/goresilience/errors"
 )
 
@@ -18,6 +19,9 @@ type AdaptiveLIFOCodelConfig struct {
 	// jobs, in case it wants to be stopped a channel could be used to
 	// stop the execution.
 	StopChannel chan struct{}
+	// RecoverPanics will recover the panics of the executions on the workers and return
+	// them as `errors.PanicError` errors, instead of crashing the program.
+	RecoverPanics bool
 }
 
 func (c *AdaptiveLIFOCodelConfig) defaults() {
@@ -55,6 +59,9 @@ type adaptiveLIFOCodel struct {
 // On the other hand the execution timeout will change based on the last time the queue was empty
 // this will give us the ability to set a lesser timeout on the queued executions when the queue
 // starts to grow.
+//
+// The queued executions are dequeued by priority class (see `goresilience.SetPriorityOnContext`)
+// and when the queue is congested the sheddable executions will be rejected directly.
 func NewAdaptiveLIFOCodel(cfg AdaptiveLIFOCodelConfig) Executor {
 
 	cfg.defaults()
@@ -69,11 +76,19 @@ func NewAdaptiveLIFOCodel(cfg AdaptiveLIFOCodelConfig) Executor {
 	return a
 }
 
-func (a *adaptiveLIFOCodel) Execute(_ context.Context, f func() error) error {
+func (a *adaptiveLIFOCodel) Execute(ctx context.Context, f func() error) error {
+	if a.cfg.RecoverPanics {
+		f = recoverFunc(ctx, f)
+	}
+
 	var timeout time.Duration
 	// If we are congested then we need to change de queuing policy to LIFO
 	// and set the congestion timeout to the aggressive CoDel timeout.
 	if a.queueCongested() {
+		// Shed the lowest priority executions first when congested.
+		if goresilience.PriorityFromContext(ctx) <= goresilience.PrioritySheddable {
+			return errors.ErrRejectedExecution
+		}
 		a.queue.SetDequeuePolicy(lifoDequeuePolicy)
 		timeout = a.cfg.CodelTargetDelay
 	} else {
@@ -117,15 +132,23 @@ func (a *adaptiveLIFOCodel) Execute(_ context.Context, f func() error) error {
 
 	// Enqueue the job in the queue that knows how to submit jobs to the worker
 	// pool afterwards.
+	qj := &queuedJob{run: job, priority: goresilience.PriorityFromContext(ctx)}
 	go func() {
-		a.queue.InChannel() <- job
+		a.queue.InChannel() <- qj
 	}()
 
-	// Wait until dequeued or timeout in queue waiting to be executed.
+	// Wait until dequeued, timeout in queue waiting to be executed or the context is done.
+	// If the timeout is reached or the context is done, we remove the job from the queue,
+	// if it has already been dequeued, it will not be executed.
 	select {
 	case <-time.After(timeout):
 		canceledJob <- struct{}{}
+		a.queue.Remove(qj)
 		return errors.ErrRejectedExecution
+	case <-ctx.Done():
+		canceledJob <- struct{}{}
+		a.queue.Remove(qj)
+		return errors.ErrContextCanceled
 	case <-dequeuedJob:
 		return <-res
 	}
@@ -139,7 +162,11 @@ func (a *adaptiveLIFOCodel) fromQueueToWorkerPool() {
 		case <-a.cfg.StopChannel:
 			return
 		case job := <-a.queue.OutChannel():
-			a.workerPool.jobQueue <- job
+			// The queue could be empty when dequeuing (e.g the job was removed).
+			if job == nil {
+				continue
+			}
+			a.workerPool.jobQueue <- job.run
 		}
 	}
 }
@@ -3,6 +3,8 @@ package execute
 import (
 	"context"
 	"sync"
+
+	"github.com/slok/goresilience/recovery"
 )
 
 // Executor knows how to limit the execution using different kind of execution workflows
@@ -12,10 +14,20 @@ import (
 type Executor interface {
 	// Execute will execute the received function and will return  the
 	// result of the executed function, or reject error from the executor.
+	// If the context is done while the function is waiting to be executed
+	// it will not be executed and `errors.ErrContextCanceled` will be returned.
 	Execute(ctx context.Context, f func() error) error
 	WorkerPool
 }
 
+// recoverFunc returns a function that will recover the panics of f and return
+// them as errors.
+func recoverFunc(ctx context.Context, f func() error) func() error {
+	return func() error {
+		return recovery.Call(ctx, f)
+	}
+}
+
 // WorkerPool maintains a worker pool what knows how to increase and decrease the worker pool.
 type WorkerPool interface {
 	SetWorkerQuantity(quantity int)
@@ -2,10 +2,16 @@ package execute_test
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience"
 	"github.com/slok/goresilience/concurrencylimit/execute"
+	grerrors "github.com/slok/goresilience/errors"
 )
 
 var benchf = func() error {
@@ -51,6 +57,16 @@ func BenchmarkExecutors(b *testing.B) {
 				return e
 			},
 		},
+		{
+			name: "Benchmark with weighted fair queue (10 workers).",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				e := execute.NewWeightedFairQueue(execute.WeightedFairQueueConfig{
+					StopChannel: stopC,
+				})
+				e.SetWorkerQuantity(10)
+				return e
+			},
+		},
 	}
 
 	for _, bench := range benchs {
@@ -79,3 +95,388 @@ func BenchmarkExecutors(b *testing.B) {
 		})
 	}
 }
+
+func TestExecutorsRecoverPanics(t *testing.T) {
+	tests := []struct {
+		name        string
+		getExecutor func(stopC chan struct{}) execute.Executor
+	}{
+		{
+			name: "A FIFO executor should recover the panics if configured.",
+			getExecutor: func(_ chan struct{}) execute.Executor {
+				return execute.NewFIFO(execute.FIFOConfig{RecoverPanics: true})
+			},
+		},
+		{
+			name: "A LIFO executor should recover the panics if configured.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewLIFO(execute.LIFOConfig{StopChannel: stopC, RecoverPanics: true})
+			},
+		},
+		{
+			name: "An adaptive LIFO + CoDel executor should recover the panics if configured.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewAdaptiveLIFOCodel(execute.AdaptiveLIFOCodelConfig{StopChannel: stopC, RecoverPanics: true})
+			},
+		},
+		{
+			name: "A weighted fair queue executor should recover the panics if configured.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewWeightedFairQueue(execute.WeightedFairQueueConfig{StopChannel: stopC, RecoverPanics: true})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			stopC := make(chan struct{})
+			defer close(stopC)
+			exec := test.getExecutor(stopC)
+			exec.SetWorkerQuantity(1)
+
+			err := exec.Execute(context.TODO(), func() error {
+				panic("wanted panic")
+			})
+
+			assert.True(errors.Is(err, grerrors.ErrPanic))
+		})
+	}
+}
+
+func TestExecutorsContextCanceled(t *testing.T) {
+	tests := []struct {
+		name        string
+		getExecutor func(stopC chan struct{}) execute.Executor
+	}{
+		{
+			name: "A FIFO executor should stop waiting when the context is done.",
+			getExecutor: func(_ chan struct{}) execute.Executor {
+				return execute.NewFIFO(execute.FIFOConfig{MaxWaitTime: 5 * time.Second})
+			},
+		},
+		{
+			name: "A LIFO executor should stop waiting when the context is done.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewLIFO(execute.LIFOConfig{StopChannel: stopC, MaxWaitTime: 5 * time.Second})
+			},
+		},
+		{
+			name: "An adaptive LIFO + CoDel executor should stop waiting when the context is done.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewAdaptiveLIFOCodel(execute.AdaptiveLIFOCodelConfig{StopChannel: stopC, CodelInterval: 5 * time.Second})
+			},
+		},
+		{
+			name: "A weighted fair queue executor should stop waiting when the context is done.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewWeightedFairQueue(execute.WeightedFairQueueConfig{StopChannel: stopC, MaxWaitTime: 5 * time.Second})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			stopC := make(chan struct{})
+			defer close(stopC)
+			exec := test.getExecutor(stopC)
+			exec.SetWorkerQuantity(1)
+
+			// Block the only worker.
+			release := make(chan struct{})
+			running := make(chan struct{})
+			go exec.Execute(context.TODO(), func() error {
+				close(running)
+				<-release
+				return nil
+			})
+			<-running
+
+			var mu sync.Mutex
+			executed := false
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := exec.Execute(ctx, func() error {
+				mu.Lock()
+				executed = true
+				mu.Unlock()
+				return nil
+			})
+			assert.Equal(grerrors.ErrContextCanceled, err)
+
+			// Release the worker and check the canceled func is not executed.
+			close(release)
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			assert.False(executed)
+			mu.Unlock()
+		})
+	}
+}
+
+func TestExecutorsPriority(t *testing.T) {
+	tests := []struct {
+		name        string
+		getExecutor func(stopC chan struct{}) execute.Executor
+		queued      []goresilience.Priority
+		expOrder    []int
+	}{
+		{
+			name: "A LIFO executor should dequeue first the latest queued executions with higher priority.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewLIFO(execute.LIFOConfig{StopChannel: stopC, MaxWaitTime: 5 * time.Second})
+			},
+			queued: []goresilience.Priority{
+				goresilience.PriorityNormal, goresilience.PriorityNormal, // Already dequeued and waiting for the worker.
+				goresilience.PrioritySheddable, goresilience.PriorityNormal, goresilience.PriorityCritical, goresilience.PriorityNormal,
+			},
+			expOrder: []int{0, 1, 4, 5, 3, 2},
+		},
+		{
+			name: "A FIFO executor should dequeue first the first queued executions with higher priority.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewFIFO(execute.FIFOConfig{StopChannel: stopC, MaxWaitTime: 5 * time.Second})
+			},
+			queued: []goresilience.Priority{
+				goresilience.PriorityNormal, goresilience.PriorityNormal, // Already dequeued and waiting for the worker.
+				goresilience.PrioritySheddable, goresilience.PriorityNormal, goresilience.PriorityCritical, goresilience.PriorityNormal,
+			},
+			expOrder: []int{0, 1, 4, 3, 5, 2},
+		},
+		{
+			name: "An adaptive LIFO + CoDel executor should dequeue first the first queued executions with higher priority.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewAdaptiveLIFOCodel(execute.AdaptiveLIFOCodelConfig{StopChannel: stopC, CodelInterval: 5 * time.Second})
+			},
+			queued: []goresilience.Priority{
+				goresilience.PriorityNormal, goresilience.PriorityNormal, // Already dequeued and waiting for the worker.
+				goresilience.PrioritySheddable, goresilience.PriorityNormal, goresilience.PriorityCritical, goresilience.PriorityNormal,
+			},
+			expOrder: []int{0, 1, 4, 3, 5, 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			stopC := make(chan struct{})
+			defer close(stopC)
+			exec := test.getExecutor(stopC)
+			exec.SetWorkerQuantity(1)
+
+			// Block the only worker.
+			release := make(chan struct{})
+			running := make(chan struct{})
+			go exec.Execute(context.TODO(), func() error {
+				close(running)
+				<-release
+				return nil
+			})
+			<-running
+
+			// Queue the executions in order.
+			var mu sync.Mutex
+			var gotOrder []int
+			var wg sync.WaitGroup
+			for i, p := range test.queued {
+				i := i
+				ctx := goresilience.SetPriorityOnContext(context.TODO(), p)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					exec.Execute(ctx, func() error {
+						mu.Lock()
+						gotOrder = append(gotOrder, i)
+						mu.Unlock()
+						return nil
+					})
+				}()
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			close(release)
+			wg.Wait()
+
+			assert.Equal(test.expOrder, gotOrder)
+		})
+	}
+}
+
+func TestExecutorsShedSheddableWhenCongested(t *testing.T) {
+	tests := []struct {
+		name        string
+		getExecutor func(stopC chan struct{}) execute.Executor
+	}{
+		{
+			name: "A FIFO executor should reject the sheddable executions when congested.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewFIFO(execute.FIFOConfig{StopChannel: stopC, MaxWaitTime: 20 * time.Millisecond})
+			},
+		},
+		{
+			name: "A LIFO executor should reject the sheddable executions when congested.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewLIFO(execute.LIFOConfig{StopChannel: stopC, MaxWaitTime: 20 * time.Millisecond})
+			},
+		},
+		{
+			name: "An adaptive LIFO + CoDel executor should reject the sheddable executions when congested.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewAdaptiveLIFOCodel(execute.AdaptiveLIFOCodelConfig{
+					StopChannel:      stopC,
+					CodelInterval:    20 * time.Millisecond,
+					CodelTargetDelay: 1 * time.Second,
+				})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			stopC := make(chan struct{})
+			defer close(stopC)
+			exec := test.getExecutor(stopC)
+			exec.SetWorkerQuantity(1)
+
+			// Block the only worker.
+			release := make(chan struct{})
+			defer close(release)
+			running := make(chan struct{})
+			go exec.Execute(context.TODO(), func() error {
+				close(running)
+				<-release
+				return nil
+			})
+			<-running
+
+			// Keep the queue filled until is congested.
+			stopFill := make(chan struct{})
+			defer close(stopFill)
+			go func() {
+				for {
+					select {
+					case <-stopFill:
+						return
+					case <-time.After(5 * time.Millisecond):
+						go exec.Execute(context.TODO(), func() error { return nil })
+					}
+				}
+			}()
+			time.Sleep(50 * time.Millisecond)
+
+			// A sheddable execution should be rejected directly.
+			start := time.Now()
+			ctx := goresilience.SetPriorityOnContext(context.TODO(), goresilience.PrioritySheddable)
+			err := exec.Execute(ctx, func() error { return nil })
+			assert.Equal(grerrors.ErrRejectedExecution, err)
+			assert.True(time.Since(start) < 10*time.Millisecond)
+		})
+	}
+}
+
+func TestAdaptiveLIFOCodelCanceledJobsDontCongest(t *testing.T) {
+	assert := assert.New(t)
+
+	stopC := make(chan struct{})
+	defer close(stopC)
+	exec := execute.NewAdaptiveLIFOCodel(execute.AdaptiveLIFOCodelConfig{
+		StopChannel:   stopC,
+		CodelInterval: 30 * time.Millisecond,
+	})
+	exec.SetWorkerQuantity(1)
+
+	// Block the only worker.
+	release := make(chan struct{})
+	running := make(chan struct{})
+	go exec.Execute(context.TODO(), func() error {
+		close(running)
+		<-release
+		return nil
+	})
+	<-running
+
+	// Fill the queue with executions that will be canceled.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		go exec.Execute(ctx, func() error { return nil })
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// The canceled executions should have been removed from the queue so it's
+	// not congested and a sheddable execution should not be rejected.
+	errC := make(chan error)
+	go func() {
+		ctx := goresilience.SetPriorityOnContext(context.TODO(), goresilience.PrioritySheddable)
+		errC <- exec.Execute(ctx, func() error { return nil })
+	}()
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+
+	assert.NoError(<-errC)
+}
+
+func TestExecutorsTimedOutJobsDontCongest(t *testing.T) {
+	tests := []struct {
+		name        string
+		getExecutor func(stopC chan struct{}) execute.Executor
+	}{
+		{
+			name: "A FIFO executor should remove the timed out executions from the queue.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewFIFO(execute.FIFOConfig{StopChannel: stopC, MaxWaitTime: 20 * time.Millisecond})
+			},
+		},
+		{
+			name: "A LIFO executor should remove the timed out executions from the queue.",
+			getExecutor: func(stopC chan struct{}) execute.Executor {
+				return execute.NewLIFO(execute.LIFOConfig{StopChannel: stopC, MaxWaitTime: 20 * time.Millisecond})
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			stopC := make(chan struct{})
+			defer close(stopC)
+			exec := test.getExecutor(stopC)
+			exec.SetWorkerQuantity(1)
+
+			// Block the only worker.
+			release := make(chan struct{})
+			running := make(chan struct{})
+			go exec.Execute(context.TODO(), func() error {
+				close(running)
+				<-release
+				return nil
+			})
+			<-running
+
+			// Fill the queue with executions that will time out.
+			for i := 0; i < 3; i++ {
+				go exec.Execute(context.TODO(), func() error { return nil })
+			}
+			time.Sleep(50 * time.Millisecond)
+
+			// The timed out executions should have been removed from the queue so it's
+			// not congested and a sheddable execution should not be rejected.
+			errC := make(chan error)
+			go func() {
+				ctx := goresilience.SetPriorityOnContext(context.TODO(), goresilience.PrioritySheddable)
+				errC <- exec.Execute(ctx, func() error { return nil })
+			}()
+			time.Sleep(5 * time.Millisecond)
+			close(release)
+
+			assert.NoError(<-errC)
+		})
+	}
+}
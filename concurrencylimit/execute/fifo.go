@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/slok/goresilience"
 	"github.com/slok/goresilience/errors"
 )
 
@@ -12,45 +13,128 @@ type FIFOConfig struct {
 	// MaxWaitTime is the max time a limiter will wait to execute before
 	// being dropped it's execution and be rejected.
 	MaxWaitTime time.Duration
+	// The fifo queue uses a goroutine in background to execute the queue
+	// jobs, in case it wants to be stopped a channel could be used to
+	// stop the execution.
+	StopChannel chan struct{}
+	// RecoverPanics will recover the panics of the executions on the workers and return
+	// them as `errors.PanicError` errors, instead of crashing the program.
+	RecoverPanics bool
 }
 
 func (c *FIFOConfig) defaults() {
 	if c.MaxWaitTime == 0 {
 		c.MaxWaitTime = 1 * time.Second
 	}
+
+	if c.StopChannel == nil {
+		c.StopChannel = make(chan struct{})
+	}
 }
 
-// NewFIFO returns a FIFO executor that will execute if there are workers available, if not it will get blocked
-// and queued with FIFO priority until one worker is free or the timeout is reached, in this last
-// case the execution will be treat as rejected.
+// NewFIFO returns a FIFO executor that will execute if there are workers available, if not it will get queued
+// with FIFO priority until one worker is free or the timeout is reached, in this last case the execution
+// will be treat as rejected. If the context is done while waiting, the execution will not be executed
+// and `errors.ErrContextCanceled` will be returned.
 //
-// The FIFO kind queue is based on internal implementation of Go channels that makes blocked sends to a
-// channel execute in a first-in-first-out priority.
+// The queued executions with a higher priority class (see `goresilience.SetPriorityOnContext`) will be
+// dequeued first, and when the queue is congested (it has not been empty for more than the max wait time)
+// the sheddable executions will be rejected directly.
 func NewFIFO(cfg FIFOConfig) Executor {
 	cfg.defaults()
 
-	return &fifo{
-		workerPool: newWorkerPool(),
+	f := &fifo{
 		cfg:        cfg,
+		queue:      newDynamicQueue(cfg.StopChannel, enqueueAtEndPolicy, fifoDequeuePolicy),
+		workerPool: newWorkerPool(),
 	}
+	go f.fromQueueToWorkerPool()
+
+	return f
 }
 
 type fifo struct {
-	cfg FIFOConfig
+	cfg   FIFOConfig
+	queue *dynamicQueue
 	workerPool
 }
 
 // Execute satisfies Executor interface.
-func (f *fifo) Execute(_ context.Context, fn func() error) error {
-	result := make(chan error, 1)
+func (f *fifo) Execute(ctx context.Context, fn func() error) error {
+	if f.cfg.RecoverPanics {
+		fn = recoverFunc(ctx, fn)
+	}
+
+	// If there is nothing queued and a worker is free, there is no need to queue.
+	if f.queue.queueIsEmpty() {
+		result := make(chan error, 1)
+		select {
+		case f.jobQueue <- func() { result <- fn() }:
+			return <-result
+		default:
+		}
+	}
+
+	// Shed the lowest priority executions first when congested.
+	priority := goresilience.PriorityFromContext(ctx)
+	if priority <= goresilience.PrioritySheddable && f.queue.SinceLastEmpty() > f.cfg.MaxWaitTime {
+		return errors.ErrRejectedExecution
+	}
+
+	// This channel will receive a signal when the job has been dequeued
+	// to be processed.
+	dequeuedJob := make(chan struct{})
+	canceledJob := make(chan struct{})
+	res := make(chan error, 1)
 	job := func() {
-		result <- fn()
+		// Send the signal the job has been dequeued.
+		close(dequeuedJob)
+
+		select {
+		case <-canceledJob:
+			return
+		default:
+		}
+
+		res <- fn()
 	}
 
+	// Send to a queue.
+	qj := &queuedJob{run: job, priority: priority}
+	go func() {
+		f.queue.InChannel() <- qj
+	}()
+
+	// If the wait time is reached or the context is done, we remove the job from
+	// the queue, if it has already been dequeued, it will not be executed.
 	select {
-	case f.jobQueue <- job:
-		return <-result
 	case <-time.After(f.cfg.MaxWaitTime):
+		close(canceledJob)
+		f.queue.Remove(qj)
 		return errors.ErrRejectedExecution
+	case <-ctx.Done():
+		close(canceledJob)
+		f.queue.Remove(qj)
+		return errors.ErrContextCanceled
+	case <-dequeuedJob:
+		return <-res
+	}
+}
+
+// fromQueueToWorkerPool will get from the queue in a loop the jobs to be
+// executed by the worker pool.
+func (f *fifo) fromQueueToWorkerPool() {
+	for {
+		select {
+		case <-f.cfg.StopChannel:
+			return
+		case job := <-f.queue.OutChannel():
+			// The queue could be empty when dequeuing (e.g the job was removed).
+			if job == nil {
+				continue
+			}
+			// Send to execution worker.
+			f.workerPool.jobQueue <- job.run
+		}
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/slok/goresilience"
 	"github.com/slok/goresilience/errors"
 )
 
@@ -17,6 +18,9 @@ type LIFOConfig struct {
 	// jobs, in case it want's to be stopped a channel could be used to
 	// stop the execution.
 	StopChannel chan struct{}
+	// RecoverPanics will recover the panics of the executions on the workers and return
+	// them as `errors.PanicError` errors, instead of crashing the program.
+	RecoverPanics bool
 }
 
 func (c *LIFOConfig) defaults() {
@@ -35,7 +39,11 @@ type lifo struct {
 	workerPool
 }
 
-// NewLIFO implements a LIFO priority executor.
+// NewLIFO implements a LIFO priority executor. The queued executions
+// with a higher priority class (see `goresilience.SetPriorityOnContext`)
+// will be dequeued first, and when the queue is congested (it has not been
+// empty for more than the max wait time) the sheddable executions will be
+// rejected directly.
 func NewLIFO(cfg LIFOConfig) Executor {
 	cfg.defaults()
 
@@ -49,7 +57,17 @@ func NewLIFO(cfg LIFOConfig) Executor {
 	return l
 }
 
-func (l *lifo) Execute(_ context.Context, f func() error) error {
+func (l *lifo) Execute(ctx context.Context, f func() error) error {
+	if l.cfg.RecoverPanics {
+		f = recoverFunc(ctx, f)
+	}
+
+	// Shed the lowest priority executions first when congested.
+	priority := goresilience.PriorityFromContext(ctx)
+	if priority <= goresilience.PrioritySheddable && l.queue.SinceLastEmpty() > l.cfg.MaxWaitTime {
+		return errors.ErrRejectedExecution
+	}
+
 	// This channel will receive a signal when the job has been dequeued
 	// to be processed.
 	dequeuedJob := make(chan struct{})
@@ -69,14 +87,22 @@ func (l *lifo) Execute(_ context.Context, f func() error) error {
 	}
 
 	// Send to a queue.
+	qj := &queuedJob{run: job, priority: priority}
 	go func() {
-		l.queue.InChannel() <- job
+		l.queue.InChannel() <- qj
 	}()
 
+	// If the wait time is reached or the context is done, we remove the job from
+	// the queue, if it has already been dequeued, it will not be executed.
 	select {
 	case <-time.After(l.cfg.MaxWaitTime):
 		close(canceledJob)
+		l.queue.Remove(qj)
 		return errors.ErrRejectedExecution
+	case <-ctx.Done():
+		close(canceledJob)
+		l.queue.Remove(qj)
+		return errors.ErrContextCanceled
 	case <-dequeuedJob:
 		return <-res
 	}
@@ -90,8 +116,12 @@ func (l *lifo) fromQueueToWorkerPool() {
 		case <-l.cfg.StopChannel:
 			return
 		case job := <-l.queue.OutChannel():
+			// The queue could be empty when dequeuing (e.g the job was removed).
+			if job == nil {
+				continue
+			}
 			// Send to execution worker.
-			l.workerPool.jobQueue <- job
+			l.workerPool.jobQueue <- job.run
 		}
 	}
 }
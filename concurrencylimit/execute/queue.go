@@ -3,23 +3,34 @@ package execute
 import (
 	"sync"
 	"time"
+
+	"github.com/slok/goresilience"
 )
 
+// queuedJob is a job on the queue with its priority.
+type queuedJob struct {
+	run      func()
+	priority goresilience.Priority
+	// canceled is set when the job is removed from the queue, so it's not
+	// queued if it was being sent to the queue at that moment.
+	canceled bool
+}
+
 // dequeuePolicy will receive a queue of jobs and return a job and the result of the
 // queue after dequeing the job.
-type dequeuePolicy func(beforeJobQ []func()) (job func(), afterJobQ []func())
+type dequeuePolicy func(beforeJobQ []*queuedJob) (job *queuedJob, afterJobQ []*queuedJob)
 
 // enqueuePolicy will receive a queue of jobs and a job and will queue the job.
-type enqueuePolicy func(job func(), beforeJobQ []func()) (afterJobQ []func())
+type enqueuePolicy func(job *queuedJob, beforeJobQ []*queuedJob) (afterJobQ []*queuedJob)
 
 // dynamicQueue is a queue that knows how to queue and dequeue objects using different kind of policies.
 // these policies can be changed with the queue is running.
 type dynamicQueue struct {
-	in            chan func()
-	out           chan func()
+	in            chan *queuedJob
+	out           chan *queuedJob
 	policyMu      sync.RWMutex
 	jobsMu        sync.Mutex
-	jobs          []func()
+	jobs          []*queuedJob
 	enqueuePolicy enqueuePolicy
 	dequeuePolicy dequeuePolicy
 	queueStats
@@ -30,8 +41,8 @@ type dynamicQueue struct {
 
 func newDynamicQueue(stopC chan struct{}, enqueuePolicy enqueuePolicy, dequeuePolicy dequeuePolicy) *dynamicQueue {
 	q := &dynamicQueue{
-		in:            make(chan func()),
-		out:           make(chan func()),
+		in:            make(chan *queuedJob),
+		out:           make(chan *queuedJob),
 		enqueuePolicy: enqueuePolicy,
 		dequeuePolicy: dequeuePolicy,
 		stopC:         stopC,
@@ -60,15 +71,30 @@ func newDynamicQueue(stopC chan struct{}, enqueuePolicy enqueuePolicy, dequeuePo
 }
 
 // InChannel returns a channel where the queue will receive the jobs.
-func (d *dynamicQueue) InChannel() chan<- func() {
+func (d *dynamicQueue) InChannel() chan<- *queuedJob {
 	return d.in
 }
 
 // OutChannel returns a channel where the jobs of the queue can be dequeued.
-func (d *dynamicQueue) OutChannel() <-chan func() {
+func (d *dynamicQueue) OutChannel() <-chan *queuedJob {
 	return d.out
 }
 
+// Remove removes the job from the queue if it's still queued.
+func (d *dynamicQueue) Remove(job *queuedJob) {
+	d.jobsMu.Lock()
+	defer d.jobsMu.Unlock()
+
+	job.canceled = true
+	for i, j := range d.jobs {
+		if j == job {
+			_, d.jobs = dequeueAt(d.jobs, i)
+			d.queueStats.decr() // Reduce in 1 the queue stats.
+			return
+		}
+	}
+}
+
 func (d *dynamicQueue) SetEnqueuePolicy(p enqueuePolicy) {
 	d.policyMu.Lock()
 	defer d.policyMu.Unlock()
@@ -87,8 +113,13 @@ func (d *dynamicQueue) enqueuer() {
 		case <-d.stopC:
 			return
 		case job := <-d.in:
-			d.queueStats.inc() // Increase in 1 the queue stats.
 			d.jobsMu.Lock()
+			// If removed while being sent, ignore it.
+			if job.canceled {
+				d.jobsMu.Unlock()
+				continue
+			}
+			d.queueStats.inc() // Increase in 1 the queue stats.
 			d.policyMu.RLock()
 			d.jobs = d.enqueuePolicy(job, d.jobs)
 			d.policyMu.RUnlock()
@@ -112,26 +143,31 @@ func (d *dynamicQueue) dequeuer() {
 			return
 		default:
 		}
+		// Get a new job, the check of the queue and the dequeue need to be made
+		// at once, otherwise the job could be removed in the middle.
+		d.jobsMu.Lock()
 		// If there are no jobs, instead of polling, sleep the dequeuer until
 		// a job enters the queue, our enqueuer will try to wake up us when any
 		// job is queued.
-		if d.queueIsEmpty() {
+		if len(d.jobs) < 1 {
+			d.jobsMu.Unlock()
 			<-d.wakeUpDequeuerC
 
 			// Check again after unblocking because could be the buffered channel signal
-			// of a queue object that we had already processed.
-			if d.queueIsEmpty() {
-				continue
-			}
+			// of a queue object that we had already processed (or removed).
+			continue
 		}
-		// Get a new job
-		var job func()
-		d.jobsMu.Lock()
+		var job *queuedJob
 		d.policyMu.RLock()
 		job, d.jobs = d.dequeuePolicy(d.jobs)
 		d.policyMu.RUnlock()
+		if job != nil {
+			d.queueStats.decr() // Reduce in 1 the queue stats.
+		}
 		d.jobsMu.Unlock()
-		d.queueStats.decr() // Reduce in 1 the queue stats.
+		if job == nil {
+			continue
+		}
 
 		// Send the correct job with the channel.
 		d.out <- job
@@ -146,33 +182,54 @@ func (d *dynamicQueue) queueIsEmpty() bool {
 
 // Queue Policies.
 // enqueueAtEndPolicy enqueues at the end of the queue.
-var enqueueAtEndPolicy = func(job func(), jobqueue []func()) []func() {
+var enqueueAtEndPolicy = func(job *queuedJob, jobqueue []*queuedJob) []*queuedJob {
 	return append(jobqueue, job)
 }
 
 // lifoDequeuePolicy implements the policy for a LIFO priority, it will
-// dequeue de latest job queue.
-var lifoDequeuePolicy = func(queue []func()) (job func(), afterQueue []func()) {
-	switch len(queue) {
-	case 0:
-		return nil, []func(){}
-	case 1:
-		return queue[0], []func(){}
-	default:
-		// LIFO order, get the last one on the queued.
-		length := len(queue)
-		return queue[length-1], queue[:length-1]
+// dequeue de latest queued job of the highest priority class on the queue.
+var lifoDequeuePolicy = func(queue []*queuedJob) (job *queuedJob, afterQueue []*queuedJob) {
+	if len(queue) == 0 {
+		return nil, []*queuedJob{}
+	}
+
+	// LIFO order, get the last one of the highest priority.
+	idx := len(queue) - 1
+	for i := len(queue) - 2; i >= 0; i-- {
+		if queue[i].priority > queue[idx].priority {
+			idx = i
+		}
 	}
+	return dequeueAt(queue, idx)
 }
 
 // fifoDequeuePolicy implements the policy for a FIFO priority, it will
-// dequeue de first job in the queue.
-var fifoDequeuePolicy = func(queue []func()) (job func(), afterQueue []func()) {
-	switch len(queue) {
+// dequeue de first queued job of the highest priority class on the queue.
+var fifoDequeuePolicy = func(queue []*queuedJob) (job *queuedJob, afterQueue []*queuedJob) {
+	if len(queue) == 0 {
+		return nil, []*queuedJob{}
+	}
+
+	// FIFO order, get the first one of the highest priority.
+	idx := 0
+	for i := 1; i < len(queue); i++ {
+		if queue[i].priority > queue[idx].priority {
+			idx = i
+		}
+	}
+	return dequeueAt(queue, idx)
+}
+
+// dequeueAt removes the job at the index position of the queue.
+func dequeueAt(queue []*queuedJob, idx int) (job *queuedJob, afterQueue []*queuedJob) {
+	job = queue[idx]
+	switch idx {
 	case 0:
-		return nil, []func(){}
+		return job, queue[1:]
+	case len(queue) - 1:
+		return job, queue[:idx]
 	default:
-		return queue[0], queue[1:]
+		return job, append(queue[:idx], queue[idx+1:]...)
 	}
 }
 
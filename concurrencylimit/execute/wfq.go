@@ -0,0 +1,322 @@
+package execute
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/metrics"
+)
+
+var ctxTenantKey contextKey = "tenant"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "execute-ctx-key" + string(c)
+}
+
+// TenantFromContext will get the tenant of the execution from the context,
+// if the context doesn't have a tenant it will return an empty tenant.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(ctxTenantKey).(string)
+	return tenant
+}
+
+// SetTenantOnContext will set the tenant of the execution on the context, this
+// is used by the WeightedFairQueue executor to queue the execution on the
+// tenant queue.
+func SetTenantOnContext(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, ctxTenantKey, tenant)
+}
+
+// WeightedFairQueueConfig is the configuration for the WeightedFairQueue executor.
+type WeightedFairQueueConfig struct {
+	// MaxWaitTime is the max time an execution will wait on its tenant queue to
+	// execute before being rejected.
+	MaxWaitTime time.Duration
+	// MaxQueuedPerTenant is the max number of executions a tenant can have waiting on
+	// its queue, when reached the new executions of the tenant will be rejected.
+	MaxQueuedPerTenant int
+	// Weights are the weights of the tenants, when multiple tenants have queued executions,
+	// a tenant with a weight of 2 will dequeue twice the executions of a tenant with a
+	// weight of 1.
+	Weights map[string]int
+	// DefaultWeight is the weight of the tenants that are not on Weights.
+	DefaultWeight int
+	// The queue uses a goroutine in background to execute the queue
+	// jobs, in case it wants to be stopped a channel could be used to
+	// stop the execution.
+	StopChannel chan struct{}
+	// RecoverPanics will recover the panics of the executions on the workers and return
+	// them as `errors.PanicError` errors, instead of crashing the program.
+	RecoverPanics bool
+}
+
+func (c *WeightedFairQueueConfig) defaults() {
+	if c.MaxWaitTime == 0 {
+		c.MaxWaitTime = 1 * time.Second
+	}
+
+	if c.MaxQueuedPerTenant <= 0 {
+		c.MaxQueuedPerTenant = 100
+	}
+
+	if c.DefaultWeight <= 0 {
+		c.DefaultWeight = 1
+	}
+
+	if c.StopChannel == nil {
+		c.StopChannel = make(chan struct{})
+	}
+}
+
+// wfqJob is a job queued on a tenant queue.
+type wfqJob struct {
+	run      func()
+	tenant   *tenantQueue
+	elem     *list.Element
+	started  bool
+	canceled bool
+	recorder metrics.Recorder
+}
+
+// tenantQueue is the queue of a tenant.
+type tenantQueue struct {
+	name    string
+	weight  int
+	deficit int
+	jobs    *list.List
+}
+
+type weightedFairQueue struct {
+	cfg WeightedFairQueueConfig
+	mu  sync.Mutex
+	// tenants are the tenants with queued jobs.
+	tenants map[string]*tenantQueue
+	// active is the round robin ring of the tenants with queued jobs.
+	active []*tenantQueue
+	// current is the position of the tenant on the ring that is being dequeued.
+	current int
+	// wakeUpDispatcherC will be used to wake up the dispatcher when jobs have been queued.
+	wakeUpDispatcherC chan struct{}
+	workerPool
+}
+
+// NewWeightedFairQueue returns an executor that maintains a queue for each tenant (set on
+// the context with `SetTenantOnContext`) and dequeues the jobs of the tenants using a
+// deficit round robin, this way a tenant with lots of queued executions can't starve the
+// executions of the other tenants.
+//
+// Each tenant will dequeue on its turn as much executions as its weight, in FIFO order.
+// When a tenant reaches the max number of queued executions, the new executions of
+// that tenant will be rejected.
+func NewWeightedFairQueue(cfg WeightedFairQueueConfig) Executor {
+	cfg.defaults()
+
+	w := &weightedFairQueue{
+		cfg:               cfg,
+		tenants:           map[string]*tenantQueue{},
+		wakeUpDispatcherC: make(chan struct{}, 1),
+		workerPool:        newWorkerPool(),
+	}
+	go w.fromQueueToWorkerPool()
+
+	return w
+}
+
+func (w *weightedFairQueue) Execute(ctx context.Context, f func() error) error {
+	if w.cfg.RecoverPanics {
+		f = recoverFunc(ctx, f)
+	}
+
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+	tenant := TenantFromContext(ctx)
+
+	// This channel will receive a signal when the job has been dequeued
+	// to be processed.
+	dequeuedJob := make(chan struct{})
+	res := make(chan error, 1)
+	job := &wfqJob{recorder: metricsRecorder}
+	job.run = func() {
+		// If the job was cancelled while being dequeued, don't execute.
+		w.mu.Lock()
+		if job.canceled {
+			w.mu.Unlock()
+			return
+		}
+		job.started = true
+		w.mu.Unlock()
+
+		// Send the signal the job has been dequeued.
+		close(dequeuedJob)
+		res <- f()
+	}
+
+	if !w.enqueue(tenant, job) {
+		metricsRecorder.IncConcurrencyLimitTenantRejected(tenant)
+		return errors.ErrRejectedExecution
+	}
+
+	timer := time.NewTimer(w.cfg.MaxWaitTime)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		if w.cancel(job) {
+			return errors.ErrRejectedExecution
+		}
+	case <-ctx.Done():
+		if w.cancel(job) {
+			return errors.ErrContextCanceled
+		}
+	case <-dequeuedJob:
+	}
+
+	return <-res
+}
+
+// enqueue will queue the job on the tenant queue, it will return false if
+// the tenant queue is full.
+func (w *weightedFairQueue) enqueue(tenant string, job *wfqJob) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	t, ok := w.tenants[tenant]
+	if !ok {
+		t = &tenantQueue{
+			name:   tenant,
+			weight: w.weight(tenant),
+			jobs:   list.New(),
+		}
+		w.tenants[tenant] = t
+		w.active = append(w.active, t)
+	}
+
+	if t.jobs.Len() >= w.cfg.MaxQueuedPerTenant {
+		return false
+	}
+
+	job.tenant = t
+	job.elem = t.jobs.PushBack(job)
+	job.recorder.SetConcurrencyLimitTenantQueuedExecutions(tenant, t.jobs.Len())
+
+	// If the dispatcher is sleeping it will get the wake up signal.
+	select {
+	case w.wakeUpDispatcherC <- struct{}{}:
+	default:
+	}
+
+	return true
+}
+
+// cancel will cancel the job, it will return false if the job has already
+// started its execution and can't be cancelled.
+func (w *weightedFairQueue) cancel(job *wfqJob) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if job.started {
+		return false
+	}
+
+	job.canceled = true
+	// If still queued, remove from the queue.
+	if job.elem != nil {
+		job.tenant.jobs.Remove(job.elem)
+		job.elem = nil
+		job.recorder.SetConcurrencyLimitTenantQueuedExecutions(job.tenant.name, job.tenant.jobs.Len())
+		w.removeIfEmpty(job.tenant)
+	}
+
+	return true
+}
+
+// dequeue will get the next job using deficit round robin, each tenant
+// on its turn will dequeue as much jobs as its weight.
+func (w *weightedFairQueue) dequeue() (*wfqJob, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.active) == 0 {
+		return nil, false
+	}
+
+	if w.current >= len(w.active) {
+		w.current = 0
+	}
+
+	// A new turn for the tenant.
+	t := w.active[w.current]
+	if t.deficit <= 0 {
+		t.deficit = t.weight
+	}
+
+	job := t.jobs.Remove(t.jobs.Front()).(*wfqJob)
+	job.elem = nil
+	t.deficit--
+	job.recorder.SetConcurrencyLimitTenantQueuedExecutions(t.name, t.jobs.Len())
+
+	// If the tenant has consumed its turn, the next tenant is the one that
+	// will dequeue.
+	if !w.removeIfEmpty(t) && t.deficit <= 0 {
+		w.current++
+	}
+
+	return job, true
+}
+
+// removeIfEmpty will remove the tenant if doesn't have queued jobs.
+func (w *weightedFairQueue) removeIfEmpty(t *tenantQueue) bool {
+	if t.jobs.Len() > 0 {
+		return false
+	}
+
+	delete(w.tenants, t.name)
+	for i, at := range w.active {
+		if at != t {
+			continue
+		}
+		w.active = append(w.active[:i], w.active[i+1:]...)
+		if i < w.current {
+			w.current--
+		}
+		break
+	}
+
+	return true
+}
+
+func (w *weightedFairQueue) weight(tenant string) int {
+	if weight := w.cfg.Weights[tenant]; weight > 0 {
+		return weight
+	}
+	return w.cfg.DefaultWeight
+}
+
+// fromQueueToWorkerPool will get the jobs from the tenant queues in a loop and
+// send them to the worker pool to be executed.
+func (w *weightedFairQueue) fromQueueToWorkerPool() {
+	for {
+		select {
+		case <-w.cfg.StopChannel:
+			return
+		case <-w.wakeUpDispatcherC:
+		}
+
+		for {
+			job, ok := w.dequeue()
+			if !ok {
+				break
+			}
+
+			select {
+			case <-w.cfg.StopChannel:
+				return
+			case w.workerPool.jobQueue <- job.run:
+			}
+		}
+	}
+}
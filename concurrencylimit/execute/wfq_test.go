@@ -0,0 +1,110 @@
+package execute_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience/concurrencylimit/execute"
+	grerrors "github.com/slok/goresilience/errors"
+)
+
+func TestWeightedFairQueue(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         execute.WeightedFairQueueConfig
+		queued      []string
+		expOrder    []string
+		expRejected []string
+	}{
+		{
+			name: "Tenants with the same weight should dequeue the executions in turns.",
+			cfg:  execute.WeightedFairQueueConfig{},
+			queued: []string{
+				"a", // Already dequeued and waiting for the worker, its tenant will start a new turn.
+				"a", "a", "a", "b", "b",
+			},
+			expOrder: []string{"a", "a", "b", "a", "b", "a"},
+		},
+		{
+			name: "Tenants with different weights should dequeue as much executions as their weight on their turn.",
+			cfg: execute.WeightedFairQueueConfig{
+				Weights: map[string]int{"a": 2},
+			},
+			queued: []string{
+				"a", // Already dequeued and waiting for the worker, its tenant will start a new turn.
+				"a", "a", "a", "a", "a", "b", "b",
+			},
+			expOrder: []string{"a", "a", "a", "b", "a", "a", "b", "a"},
+		},
+		{
+			name: "A tenant with the queue full should have its executions rejected without affecting the other tenants.",
+			cfg: execute.WeightedFairQueueConfig{
+				MaxQueuedPerTenant: 2,
+			},
+			queued: []string{
+				"a", // Already dequeued and waiting for the worker, its tenant will start a new turn.
+				"a", "a", "a", "a", "b", "b",
+			},
+			expOrder:    []string{"a", "a", "b", "a", "b"},
+			expRejected: []string{"a", "a"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			stopC := make(chan struct{})
+			defer close(stopC)
+			test.cfg.StopChannel = stopC
+			test.cfg.MaxWaitTime = 5 * time.Second
+			exec := execute.NewWeightedFairQueue(test.cfg)
+			exec.SetWorkerQuantity(1)
+
+			// Block the only worker.
+			release := make(chan struct{})
+			running := make(chan struct{})
+			go exec.Execute(context.TODO(), func() error {
+				close(running)
+				<-release
+				return nil
+			})
+			<-running
+
+			// Queue the executions in order.
+			var mu sync.Mutex
+			var gotOrder, gotRejected []string
+			var wg sync.WaitGroup
+			for _, tenant := range test.queued {
+				tenant := tenant
+				ctx := execute.SetTenantOnContext(context.TODO(), tenant)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := exec.Execute(ctx, func() error {
+						mu.Lock()
+						gotOrder = append(gotOrder, tenant)
+						mu.Unlock()
+						return nil
+					})
+					if err == grerrors.ErrRejectedExecution {
+						mu.Lock()
+						gotRejected = append(gotRejected, tenant)
+						mu.Unlock()
+					}
+				}()
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			close(release)
+			wg.Wait()
+
+			assert.Equal(test.expOrder, gotOrder)
+			assert.Equal(test.expRejected, gotRejected)
+		})
+	}
+}
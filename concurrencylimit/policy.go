@@ -14,15 +14,16 @@ import (
 type ExecutionResultPolicy func(ctx context.Context, err error) limit.Result
 
 // FailureOnExternalErrorPolicy will treat as failure every error that is not
-// from concurrencylimit package (this is the error by the limiters).
+// from concurrencylimit package (this is the error by the limiters) nor a context
+// cancellation.
 var FailureOnExternalErrorPolicy = func(_ context.Context, err error) limit.Result {
 	// Everything ok.
 	if err == nil {
 		return limit.ResultSuccess
 	}
 
-	// Our own failures should be ignored, the rest nope.
-	if err != nil && err != errors.ErrRejectedExecution {
+	// Our own failures and the cancellations should be ignored, the rest nope.
+	if err != nil && err != errors.ErrRejectedExecution && err != errors.ErrContextCanceled {
 		return limit.ResultFailure
 	}
 
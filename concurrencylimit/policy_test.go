@@ -36,6 +36,12 @@ func TestPolicies(t *testing.T) {
 			err:       goresilienceerrors.ErrRejectedExecution,
 			expResult: limit.ResultIgnore,
 		},
+		{
+			name:      "FailureOnExternalErrorPolicy with a context cancellation should return ignore",
+			policy:    concurrencylimit.FailureOnExternalErrorPolicy,
+			err:       goresilienceerrors.ErrContextCanceled,
+			expResult: limit.ResultIgnore,
+		},
 		{
 			name:      "NoFailurePolicy no failure should return success",
 			policy:    concurrencylimit.NoFailurePolicy,
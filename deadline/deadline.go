@@ -0,0 +1,119 @@
+package deadline
+
+import (
+	"context"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/errors"
+)
+
+var ctxBudgetKey contextKey = "budget"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "deadline-ctx-key" + string(c)
+}
+
+// Budget is the time budget of an execution based on the context deadline. The
+// runners that know about the budget (like timeout or retry) will use it to
+// size their executions, e.g: a retry runner with 3 attempts left and 300ms of
+// budget will give 100ms to each attempt.
+type Budget struct {
+	// Deadline is the deadline of the budget (the context deadline minus the margin).
+	Deadline time.Time
+	// MinBudget is the minimum time required to make an execution.
+	MinBudget time.Duration
+}
+
+// Remaining returns the remaining time of the budget.
+func (b Budget) Remaining() time.Duration {
+	return time.Until(b.Deadline)
+}
+
+// Split returns the remaining time of the budget split in n equal parts.
+func (b Budget) Split(n int) time.Duration {
+	if n <= 1 {
+		return b.Remaining()
+	}
+	return b.Remaining() / time.Duration(n)
+}
+
+// Enough returns if the budget is enough to make an execution.
+func (b Budget) Enough(d time.Duration) bool {
+	return d >= b.MinBudget && d > 0
+}
+
+// BudgetFromContext will get the deadline budget of the execution from the context.
+func BudgetFromContext(ctx context.Context) (budget Budget, ok bool) {
+	budget, ok = ctx.Value(ctxBudgetKey).(Budget)
+	return budget, ok
+}
+
+// SetBudgetOnContext will set the deadline budget of the execution on the context.
+func SetBudgetOnContext(ctx context.Context, budget Budget) context.Context {
+	return context.WithValue(ctx, ctxBudgetKey, budget)
+}
+
+// Config is the configuration of the deadline runner.
+type Config struct {
+	// Margin is the time reserved from the context deadline that will not be
+	// used by the execution, e.g: to have time to respond to the caller.
+	Margin time.Duration
+	// MinBudget is the minimum remaining time required to make an execution, if the
+	// remaining time is less, the execution will fail fast without being executed.
+	MinBudget time.Duration
+}
+
+func (c *Config) defaults() {
+	if c.Margin < 0 {
+		c.Margin = 0
+	}
+
+	if c.MinBudget < 0 {
+		c.MinBudget = 0
+	}
+}
+
+// New returns a new deadline runner, it will set a deadline budget on the context
+// based on the context deadline, so the next runners can use it to size their
+// executions (e.g the timeout of each attempt of a retry runner). If the context
+// doesn't have a deadline, it will do nothing.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a new deadline middleware, it will set a deadline budget on
+// the context based on the context deadline, so the next runners can use it to size
+// their executions (e.g the timeout of each attempt of a retry runner). If the context
+// doesn't have a deadline, it will do nothing.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		next = goresilience.SanitizeRunner(next)
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			d, ok := ctx.Deadline()
+			if !ok {
+				return next.Run(ctx, f)
+			}
+
+			budget := Budget{
+				Deadline:  d.Add(-cfg.Margin),
+				MinBudget: cfg.MinBudget,
+			}
+
+			// Fail fast if we don't have time to execute.
+			if !budget.Enough(budget.Remaining()) {
+				return errors.ErrDeadlineBudgetExhausted
+			}
+
+			ctx, cancel := context.WithDeadline(ctx, budget.Deadline)
+			defer cancel()
+			ctx = SetBudgetOnContext(ctx, budget)
+
+			return next.Run(ctx, f)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package deadline_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/deadline"
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/hedge"
+	"github.com/slok/goresilience/retry"
+	"github.com/slok/goresilience/timeout"
+)
+
+func TestDeadline(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           deadline.Config
+		ctxTimeout    time.Duration
+		expBudget     bool
+		expRemaining  time.Duration
+		expExecutions int
+		expErr        error
+	}{
+		{
+			name:          "A context without deadline should not have budget.",
+			cfg:           deadline.Config{Margin: 10 * time.Millisecond},
+			expBudget:     false,
+			expExecutions: 1,
+		},
+		{
+			name:          "A context with deadline should have the budget of the deadline minus the margin.",
+			cfg:           deadline.Config{Margin: 100 * time.Millisecond},
+			ctxTimeout:    300 * time.Millisecond,
+			expBudget:     true,
+			expRemaining:  200 * time.Millisecond,
+			expExecutions: 1,
+		},
+		{
+			name:          "A context with a deadline lower than the min budget should fail fast.",
+			cfg:           deadline.Config{Margin: 10 * time.Millisecond, MinBudget: 50 * time.Millisecond},
+			ctxTimeout:    50 * time.Millisecond,
+			expExecutions: 0,
+			expErr:        grerrors.ErrDeadlineBudgetExhausted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			if test.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.ctxTimeout)
+				defer cancel()
+			}
+
+			executions := 0
+			var gotBudget deadline.Budget
+			var gotOK bool
+			var gotRemaining time.Duration
+			cmd := deadline.New(test.cfg)
+			err := cmd.Run(ctx, func(ctx context.Context) error {
+				executions++
+				gotBudget, gotOK = deadline.BudgetFromContext(ctx)
+				if d, ok := ctx.Deadline(); ok {
+					gotRemaining = time.Until(d)
+					assert.Equal(gotBudget.Deadline, d)
+				}
+				return nil
+			})
+
+			assert.Equal(test.expErr, err)
+			assert.Equal(test.expExecutions, executions)
+			assert.Equal(test.expBudget, gotOK)
+			if test.expBudget {
+				assert.InDelta(test.expRemaining, gotRemaining, float64(20*time.Millisecond))
+			}
+		})
+	}
+}
+
+func TestDeadlineBudgetSplit(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           deadline.Config
+		ctxTimeout    time.Duration
+		expExecutions int
+		expErr        error
+	}{
+		{
+			name:          "The budget should be split between all the attempts of the retries.",
+			cfg:           deadline.Config{},
+			ctxTimeout:    200 * time.Millisecond,
+			expExecutions: 4,
+		},
+		{
+			name:          "If the budget split is less than the min budget, it should fail fast.",
+			cfg:           deadline.Config{MinBudget: 40 * time.Millisecond},
+			ctxTimeout:    100 * time.Millisecond,
+			expExecutions: 0,
+			expErr:        grerrors.ErrDeadlineBudgetExhausted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), test.ctxTimeout)
+			defer cancel()
+
+			var mu sync.Mutex
+			executions := 0
+			cmd := goresilience.RunnerChain(
+				deadline.NewMiddleware(test.cfg),
+				retry.NewMiddleware(retry.Config{
+					Backoff: retry.NewConstantBackoff(1 * time.Nanosecond),
+					Times:   3,
+				}),
+				timeout.NewMiddleware(timeout.Config{Timeout: 1 * time.Second}),
+			)
+			err := cmd.Run(ctx, func(ctx context.Context) error {
+				mu.Lock()
+				executions++
+				mu.Unlock()
+				<-ctx.Done()
+				return ctx.Err()
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(test.expExecutions, executions)
+			if test.expErr != nil {
+				assert.True(errors.Is(err, test.expErr))
+			}
+		})
+	}
+}
+
+func TestDeadlineBudgetSplitOnRetryWithHedge(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// The hedge runner inside the retry runner should not hide the retry attempts.
+	var mu sync.Mutex
+	executions := 0
+	cmd := goresilience.RunnerChain(
+		deadline.NewMiddleware(deadline.Config{}),
+		retry.NewMiddleware(retry.Config{
+			Backoff: retry.NewConstantBackoff(1 * time.Nanosecond),
+			Times:   3,
+		}),
+		hedge.NewMiddleware(hedge.Config{Delay: 1 * time.Hour}),
+		timeout.NewMiddleware(timeout.Config{Timeout: 1 * time.Second}),
+	)
+	_ = cmd.Run(ctx, func(ctx context.Context) error {
+		mu.Lock()
+		executions++
+		mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(4, executions)
+}
+
+func TestDeadlineBudgetNotSplitOnHedge(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+
+	// The hedged executions are concurrent so they should have all the budget
+	// even if no hedged execution is sent.
+	cmd := goresilience.RunnerChain(
+		deadline.NewMiddleware(deadline.Config{}),
+		hedge.NewMiddleware(hedge.Config{MaxHedges: 3, Delay: 1 * time.Hour}),
+		timeout.NewMiddleware(timeout.Config{Timeout: 1 * time.Second}),
+	)
+	err := cmd.Run(ctx, func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+			return nil
+		}
+	})
+
+	assert.NoError(err)
+}
@@ -1,5 +1,10 @@
 package errors
 
+import (
+	"fmt"
+	"runtime/debug"
+)
+
 // Error is a goresilience error. Although satisfies Error interface from Golang
 // this gives us the ability to check if the error was triggered by a Runner or not.
 type Error string
@@ -25,4 +30,52 @@ var (
 	// ErrRejectedExecution will be used by the executors when the execution of a func has been rejected
 	// before being executed.
 	ErrRejectedExecution = Error("execution has been rejected")
+	// ErrRateLimited will be used when the execution has been rejected by the rate limiter
+	// because the allowed rate has been exceeded.
+	ErrRateLimited = Error("execution rate limited")
+	// ErrPanic will be used when the execution panicked and the panic has been recovered.
+	ErrPanic = Error("execution panicked")
+	// ErrRetryMaxElapsedTime will be used when the retries have been aborted because
+	// the next retry would exceed the maximum time allowed for all the retries.
+	ErrRetryMaxElapsedTime = Error("retry max elapsed time exceeded")
+	// ErrRetryDeadlineExceeded will be used when the retries have been aborted because
+	// the wait before the next retry would exceed the context deadline.
+	ErrRetryDeadlineExceeded = Error("retry wait exceeds the context deadline")
+	// ErrRetryBudgetExhausted will be used when the retries have been aborted because
+	// the retry budget doesn't have enough tokens for a new retry.
+	ErrRetryBudgetExhausted = Error("retry budget exhausted")
+	// ErrDeadlineBudgetExhausted will be used when the execution has not been executed
+	// because the remaining time until the deadline is not enough to execute it.
+	ErrDeadlineBudgetExhausted = Error("not enough deadline budget for the execution")
+	// ErrShutdown will be used when the execution has not been executed because the
+	// runner has been shut down.
+	ErrShutdown = Error("runner has been shut down")
 )
+
+// PanicError is the error used when a panic has been recovered, it has the panic
+// value and the stack trace of the panic. It wraps ErrPanic so it can be checked
+// with `errors.Is(err, ErrPanic)`.
+type PanicError struct {
+	// Value is the value the execution panicked with.
+	Value interface{}
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// NewPanicError returns a new PanicError with the current goroutine stack trace,
+// it should be called on the deferred function that recovered the panic.
+func NewPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s: %v", ErrPanic, e.Value)
+}
+
+// Unwrap returns ErrPanic.
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
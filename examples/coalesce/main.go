@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience/coalesce"
+)
+
+func main() {
+	runner := coalesce.NewOf[string](coalesce.Config{})
+
+	// Simulate a cache miss of the same key at the same time on
+	// multiple goroutines, only one will reach our backend.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx := coalesce.SetKeyOnContext(context.TODO(), "user-1234")
+			res, err := runner.Run(ctx, func(_ context.Context) (string, error) {
+				fmt.Printf("[%d] getting user from the backend\n", i)
+				time.Sleep(100 * time.Millisecond)
+				return "Bruce Wayne", nil
+			})
+
+			fmt.Printf("[%d] result: %s (err: %v)\n", i, res, err)
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/circuitbreaker"
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/fallback"
+	"github.com/slok/goresilience/timeout"
+)
+
+func main() {
+	// Create our execution chain, we will only fallback when the circuit
+	// is open or the execution timeouts.
+	runner := goresilience.RunnerChainOf(
+		fallback.NewMiddlewareOf(fallback.ConfigOf[string]{
+			ShouldFallback: fallback.OnErrors(grerrors.ErrCircuitOpen, grerrors.ErrTimeout),
+			Fallback: func(_ context.Context, err error) (string, error) {
+				return "not ok, but fallback", nil
+			},
+		}),
+		goresilience.AdaptMiddleware[string](circuitbreaker.NewMiddleware(circuitbreaker.Config{})),
+		goresilience.AdaptMiddleware[string](timeout.NewMiddleware(timeout.Config{
+			Timeout: 100 * time.Millisecond,
+		})),
+	)
+
+	for i := 0; i < 200; i++ {
+		// Execute.
+		result, err := runner.Run(context.TODO(), func(_ context.Context) (string, error) {
+			switch time.Now().Nanosecond() % 3 {
+			case 0:
+				time.Sleep(200 * time.Millisecond)
+			case 1:
+				return "", errors.New("you didn't expect this error")
+			}
+			return "all ok", nil
+		})
+
+		if err != nil {
+			log.Printf("the error is: %s", err)
+			continue
+		}
+
+		log.Printf("the result is: %s", result)
+	}
+}
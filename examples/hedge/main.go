@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/slok/goresilience/hedge"
+)
+
+func main() {
+	// Send a hedged execution when the execution is slower than the p95
+	// latency. At most 10% of the executions will be hedged.
+	runner := hedge.New(hedge.Config{
+		Delay:             50 * time.Millisecond,
+		LatencyPercentile: 95,
+	})
+
+	for i := 0; i < 500; i++ {
+		start := time.Now()
+		err := runner.Run(context.TODO(), func(ctx context.Context) error {
+			// Simulate a latency with a long tail.
+			lat := 10 * time.Millisecond
+			if rand.Intn(100) < 3 {
+				lat = 1 * time.Second
+			}
+
+			select {
+			case <-time.After(lat):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		fmt.Printf("[%d] finished in %s (err: %v)\n", i, time.Since(start), err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/circuitbreaker"
+	"github.com/slok/goresilience/keyed"
+)
+
+var hosts = []string{"host-a", "host-b", "host-c"}
+
+func main() {
+	// Every host will have its own circuit breaker.
+	runner := keyed.New(keyed.Config{
+		Factory: func(_ string) goresilience.Middleware {
+			return circuitbreaker.NewMiddleware(circuitbreaker.Config{
+				MinimumRequestToOpen: 5,
+			})
+		},
+		MaxKeys: 100,
+		IdleTTL: 5 * time.Minute,
+	})
+
+	for i := 0; i < 60; i++ {
+		host := hosts[i%len(hosts)]
+		ctx := keyed.SetKeyOnContext(context.TODO(), host)
+
+		err := runner.Run(ctx, func(_ context.Context) error {
+			// Only host-b is failing.
+			if host == "host-b" {
+				return fmt.Errorf("%s is down", host)
+			}
+			return nil
+		})
+
+		fmt.Printf("[%s] result: %v\n", host, err)
+	}
+}
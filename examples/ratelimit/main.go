@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slok/goresilience/ratelimit"
+)
+
+const (
+	times = 100
+)
+
+func main() {
+	// Allow 20 executions per second with bursts of 5, wait at most
+	// 100ms for the execution to be allowed.
+	runner := ratelimit.New(ratelimit.Config{
+		Rate:        20,
+		Burst:       5,
+		MaxWaitTime: 100 * time.Millisecond,
+	})
+
+	for i := 0; i < times; i++ {
+		i := i
+
+		// Every 10 cmd executions we wait.
+		if i%10 == 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		go func() {
+			err := runner.Run(context.TODO(), func(_ context.Context) error {
+				fmt.Printf("[%d] executed\n", i)
+				return nil
+			})
+
+			if err != nil {
+				fmt.Printf("[%d] error: %s\n", i, err)
+			}
+		}()
+	}
+
+	time.Sleep(1 * time.Second)
+}
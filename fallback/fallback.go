@@ -0,0 +1,138 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/metrics"
+)
+
+// Func is the function that will be executed as a fallback, it receives
+// the error of the failed execution.
+type Func func(ctx context.Context, err error) error
+
+// FuncOf is like Func but for the typed runners, it returns the fallback result.
+type FuncOf[T any] func(ctx context.Context, err error) (T, error)
+
+// Predicate will decide based on the error of the execution if the fallback
+// should be executed or not.
+type Predicate func(ctx context.Context, err error) bool
+
+// OnAnyError is a Predicate that will execute the fallback on any error.
+var OnAnyError Predicate = func(_ context.Context, err error) bool {
+	return err != nil
+}
+
+// OnErrors returns a Predicate that will only execute the fallback when the
+// execution error is one of the received errors, for example
+// `errors.ErrCircuitOpen` or `errors.ErrTimeout`.
+func OnErrors(errs ...error) Predicate {
+	return func(_ context.Context, err error) bool {
+		for _, e := range errs {
+			if errors.Is(err, e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Config is the configuration of the fallback runner.
+type Config struct {
+	// Fallback is the function that will be executed when the execution fails.
+	Fallback Func
+	// ShouldFallback decides if the fallback needs to be executed based on the
+	// execution error. By default every error will execute the fallback.
+	ShouldFallback Predicate
+}
+
+func (c *Config) defaults() {
+	if c.Fallback == nil {
+		c.Fallback = func(_ context.Context, err error) error { return err }
+	}
+
+	if c.ShouldFallback == nil {
+		c.ShouldFallback = OnAnyError
+	}
+}
+
+// ConfigOf is the configuration of the typed fallback runner.
+type ConfigOf[T any] struct {
+	// Fallback is the function that will be executed when the execution fails.
+	Fallback FuncOf[T]
+	// ShouldFallback decides if the fallback needs to be executed based on the
+	// execution error. By default every error will execute the fallback.
+	ShouldFallback Predicate
+}
+
+func (c *ConfigOf[T]) defaults() {
+	if c.Fallback == nil {
+		c.Fallback = func(_ context.Context, err error) (T, error) {
+			var zero T
+			return zero, err
+		}
+	}
+
+	if c.ShouldFallback == nil {
+		c.ShouldFallback = OnAnyError
+	}
+}
+
+// New returns a new fallback runner. The fallback runner will execute
+// the fallback Func when the execution fails and the error satisfies
+// the ShouldFallback predicate, the result of the fallback will be the
+// result of the execution.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that uses the Runner returned
+// by fallback.New.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		next = goresilience.SanitizeRunner(next)
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			err := next.Run(ctx, f)
+			if err == nil || !cfg.ShouldFallback(ctx, err) {
+				return err
+			}
+
+			metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+			err = cfg.Fallback(ctx, err)
+			metricsRecorder.IncFallback(err == nil)
+
+			return err
+		})
+	}
+}
+
+// NewOf is like New but for typed runners, the typed fallback result
+// will be returned when the execution fails.
+func NewOf[T any](cfg ConfigOf[T]) goresilience.RunnerOf[T] {
+	return NewMiddlewareOf(cfg)(nil)
+}
+
+// NewMiddlewareOf returns a typed middleware that uses the Runner returned
+// by fallback.NewOf.
+func NewMiddlewareOf[T any](cfg ConfigOf[T]) goresilience.MiddlewareOf[T] {
+	cfg.defaults()
+
+	return func(next goresilience.RunnerOf[T]) goresilience.RunnerOf[T] {
+		next = goresilience.SanitizeRunnerOf(next)
+		return goresilience.RunnerOfFunc[T](func(ctx context.Context, f goresilience.FuncOf[T]) (T, error) {
+			res, err := next.Run(ctx, f)
+			if err == nil || !cfg.ShouldFallback(ctx, err) {
+				return res, err
+			}
+
+			metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+			res, err = cfg.Fallback(ctx, err)
+			metricsRecorder.IncFallback(err == nil)
+
+			return res, err
+		})
+	}
+}
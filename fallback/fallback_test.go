@@ -0,0 +1,154 @@
+package fallback_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience"
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/fallback"
+)
+
+var errWanted = errors.New("wanted error")
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            fallback.Config
+		f              goresilience.Func
+		expErr         error
+		expFallbackErr error
+		expFallback    bool
+	}{
+		{
+			name: "A successful execution should not execute the fallback.",
+			cfg:  fallback.Config{},
+			f: func(_ context.Context) error {
+				return nil
+			},
+			expErr:      nil,
+			expFallback: false,
+		},
+		{
+			name: "A failed execution should execute the fallback and return its result.",
+			cfg:  fallback.Config{},
+			f: func(_ context.Context) error {
+				return errWanted
+			},
+			expErr:         nil,
+			expFallbackErr: errWanted,
+			expFallback:    true,
+		},
+		{
+			name: "A failed execution with an error that is not on the fallback errors should not execute the fallback.",
+			cfg: fallback.Config{
+				ShouldFallback: fallback.OnErrors(grerrors.ErrCircuitOpen, grerrors.ErrTimeout),
+			},
+			f: func(_ context.Context) error {
+				return errWanted
+			},
+			expErr:      errWanted,
+			expFallback: false,
+		},
+		{
+			name: "A failed execution with an error that is on the fallback errors should execute the fallback.",
+			cfg: fallback.Config{
+				ShouldFallback: fallback.OnErrors(grerrors.ErrCircuitOpen, grerrors.ErrTimeout),
+			},
+			f: func(_ context.Context) error {
+				return grerrors.ErrTimeout
+			},
+			expErr:         nil,
+			expFallbackErr: grerrors.ErrTimeout,
+			expFallback:    true,
+		},
+		{
+			name: "A failed execution that the predicate decides to not fallback should not execute the fallback.",
+			cfg: fallback.Config{
+				ShouldFallback: func(_ context.Context, err error) bool { return false },
+			},
+			f: func(_ context.Context) error {
+				return errWanted
+			},
+			expErr:      errWanted,
+			expFallback: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotFallback bool
+			var gotFallbackErr error
+			test.cfg.Fallback = func(_ context.Context, err error) error {
+				gotFallback = true
+				gotFallbackErr = err
+				return nil
+			}
+
+			runner := fallback.New(test.cfg)
+			err := runner.Run(context.TODO(), test.f)
+
+			assert.Equal(test.expErr, err)
+			assert.Equal(test.expFallback, gotFallback)
+			assert.Equal(test.expFallbackErr, gotFallbackErr)
+		})
+	}
+}
+
+func TestFallbackOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       fallback.ConfigOf[string]
+		f         goresilience.FuncOf[string]
+		expResult string
+		expErr    error
+	}{
+		{
+			name: "A successful execution should return the execution result.",
+			cfg: fallback.ConfigOf[string]{
+				Fallback: func(_ context.Context, _ error) (string, error) { return "fallback", nil },
+			},
+			f: func(_ context.Context) (string, error) {
+				return "ok", nil
+			},
+			expResult: "ok",
+		},
+		{
+			name: "A failed execution should return the fallback result.",
+			cfg: fallback.ConfigOf[string]{
+				Fallback: func(_ context.Context, _ error) (string, error) { return "fallback", nil },
+			},
+			f: func(_ context.Context) (string, error) {
+				return "", errWanted
+			},
+			expResult: "fallback",
+		},
+		{
+			name: "A failed fallback should return the fallback error.",
+			cfg: fallback.ConfigOf[string]{
+				Fallback: func(_ context.Context, err error) (string, error) { return "", err },
+			},
+			f: func(_ context.Context) (string, error) {
+				return "", errWanted
+			},
+			expErr: errWanted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := fallback.NewOf(test.cfg)
+			res, err := runner.Run(context.TODO(), test.f)
+
+			assert.Equal(test.expErr, err)
+			assert.Equal(test.expResult, res)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package hedge
+
+import (
+	"context"
+)
+
+var ctxAttemptKey contextKey = "attempt"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "hedge-ctx-key" + string(c)
+}
+
+// Attempt has the information of the current execution of a hedged request, the
+// hedge runner will set it on the context of every execution. It uses its own
+// context key so it doesn't replace the attempt information of a retry runner
+// (`retry.AttemptFromContext`) when both runners are nested.
+type Attempt struct {
+	// Number is the number of the execution, the original execution is the attempt 1.
+	Number int
+	// Total is the total number of executions allowed (the original and the hedged ones).
+	Total int
+	// PrevErr is the error of the last failed execution when the execution started, nil
+	// if none failed.
+	PrevErr error
+}
+
+// AttemptFromContext will get the hedge attempt information of the execution from the context.
+func AttemptFromContext(ctx context.Context) (attempt Attempt, ok bool) {
+	attempt, ok = ctx.Value(ctxAttemptKey).(Attempt)
+	return attempt, ok
+}
+
+// SetAttemptOnContext will set the hedge attempt information of the execution on the context.
+func SetAttemptOnContext(ctx context.Context, attempt Attempt) context.Context {
+	return context.WithValue(ctx, ctxAttemptKey, attempt)
+}
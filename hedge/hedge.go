@@ -0,0 +1,215 @@
+package hedge
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/internal/latency"
+	"github.com/slok/goresilience/metrics"
+)
+
+// Config is the configuration of the hedge runner.
+type Config struct {
+	// Delay is the duration that will be waited before sending a new hedged
+	// execution if the previous ones haven't finished. When the delay is derived
+	// from the latency percentile, this delay will be used until there are
+	// enough samples.
+	Delay time.Duration
+	// LatencyPercentile is the percentile (0-100) of the observed latency of the
+	// successful executions that will be used as the delay, e.g: 95 will send
+	// a hedged execution when the execution takes more than the 95th percentile.
+	// If 0 the static Delay will be used.
+	LatencyPercentile float64
+	// MinimumSamples is the number of latency samples required to start using the
+	// latency percentile as the delay.
+	MinimumSamples int
+	// MaxHedges is the max number of hedged executions (without the original one)
+	// that will be made for an execution.
+	MaxHedges int
+	// MaxHedgePercent is the max percent of hedged executions based on the total
+	// executions, this way the hedged executions can't multiply the load in
+	// case of an incident (the default is 10% more load at most).
+	MaxHedgePercent int
+	// MaxHedgeBurst is the max number of hedged executions that can be made in a
+	// burst, the allowance of hedged executions not used on the calm periods is
+	// capped to this, so it can't be used all at once on an incident.
+	MaxHedgeBurst int
+}
+
+func (c *Config) defaults() {
+	if c.Delay <= 0 {
+		c.Delay = 100 * time.Millisecond
+	}
+
+	if c.LatencyPercentile < 0 || c.LatencyPercentile > 100 {
+		c.LatencyPercentile = 0
+	}
+
+	if c.MinimumSamples <= 0 {
+		c.MinimumSamples = 100
+	}
+
+	if c.MaxHedges <= 0 {
+		c.MaxHedges = 1
+	}
+
+	if c.MaxHedgePercent <= 0 {
+		c.MaxHedgePercent = 10
+	}
+
+	if c.MaxHedgeBurst <= 0 {
+		c.MaxHedgeBurst = 10
+	}
+
+	// At least one execution needs to be able to send all its hedged executions.
+	if c.MaxHedgeBurst < c.MaxHedges {
+		c.MaxHedgeBurst = c.MaxHedges
+	}
+}
+
+type hedge struct {
+	cfg       Config
+	latencies *latency.Histogram
+	// allowance is the percent of a hedged execution available (100 is a hedged execution).
+	allowance int
+	mu        sync.Mutex
+	runner    goresilience.Runner
+}
+
+// New returns a new hedge runner.
+//
+// The hedge runner will execute the Func and if it hasn't finished after
+// a delay it will execute the same Func again concurrently (a hedged execution)
+// up to N hedged executions. The first successful result will be returned
+// and the context of the other executions will be cancelled.
+//
+// The delay can be static or derived from the observed latency percentile
+// of the executions. The number of hedged executions is capped using a max
+// percent of the total executions and a max burst of hedged executions.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that uses the Runner returned
+// by hedge.New.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	// The old latency samples lose weight halving the samples, keep at least 2 times
+	// the minimum samples so the delay doesn't go back to the static one.
+	maxSamples := 1000
+	if maxSamples < 2*cfg.MinimumSamples {
+		maxSamples = 2 * cfg.MinimumSamples
+	}
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &hedge{
+			cfg:       cfg,
+			latencies: latency.NewHistogram(maxSamples),
+			runner:    goresilience.SanitizeRunner(next),
+		}
+	}
+}
+
+// execution is the result of one of the executions.
+type execution struct {
+	hedged bool
+	err    error
+}
+
+func (h *hedge) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+	start := time.Now()
+
+	// When we return we don't need the other executions.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The results channel is buffered so the executions that lost
+	// don't get blocked.
+	resC := make(chan execution, h.cfg.MaxHedges+1)
+	var err error
+	launched := 0
+	launch := func(hedged bool) {
+		launched++
+		attemptCtx := SetAttemptOnContext(ctx, Attempt{
+			Number:  launched,
+			Total:   h.cfg.MaxHedges + 1,
+			PrevErr: err,
+		})
+		go func() {
+			err := h.runner.Run(attemptCtx, f)
+			resC <- execution{hedged: hedged, err: err}
+		}()
+	}
+
+	h.incExecutions()
+	launch(false)
+
+	delay := h.delay()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for finished := 0; finished < launched; {
+		select {
+		case res := <-resC:
+			finished++
+			if res.err == nil {
+				// The latency is the one of the request, not the one of the winner execution,
+				// otherwise the hedged executions would lower the delay over time.
+				h.latencies.Observe(time.Since(start))
+				if res.hedged {
+					metricsRecorder.IncHedgeWon()
+				}
+				return nil
+			}
+			err = res.err
+		case <-timer.C:
+			if launched <= h.cfg.MaxHedges && h.allowHedge() {
+				metricsRecorder.IncHedge()
+				launch(true)
+				timer.Reset(delay)
+			}
+		}
+	}
+
+	// All the executions failed.
+	return err
+}
+
+// delay returns the duration to wait before a hedged execution.
+func (h *hedge) delay() time.Duration {
+	if h.cfg.LatencyPercentile == 0 || h.latencies.Samples() < h.cfg.MinimumSamples {
+		return h.cfg.Delay
+	}
+
+	return h.latencies.Percentile(h.cfg.LatencyPercentile)
+}
+
+// incExecutions will add the max percent of hedged executions of an execution
+// to the hedged executions allowance, up to the max burst.
+func (h *hedge) incExecutions() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.allowance += h.cfg.MaxHedgePercent
+	if max := h.cfg.MaxHedgeBurst * 100; h.allowance > max {
+		h.allowance = max
+	}
+}
+
+// allowHedge will check if a new hedged execution doesn't exceed the max
+// percent of hedged executions, if allowed it will count it.
+func (h *hedge) allowHedge() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.allowance < 100 {
+		return false
+	}
+
+	h.allowance -= 100
+	return true
+}
@@ -0,0 +1,253 @@
+package hedge_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience/hedge"
+)
+
+var errWanted = errors.New("wanted error")
+
+// execution simulates the executions of a Func based on the call number.
+type executions struct {
+	calls    int
+	canceled int
+	durs     []time.Duration
+	errs     []error
+	mu       sync.Mutex
+}
+
+func (e *executions) Run(ctx context.Context) error {
+	e.mu.Lock()
+	i := e.calls
+	e.calls++
+	e.mu.Unlock()
+
+	select {
+	case <-time.After(e.durs[i]):
+		return e.errs[i]
+	case <-ctx.Done():
+		e.mu.Lock()
+		e.canceled++
+		e.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+func TestHedge(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         hedge.Config
+		execs       *executions
+		expErr      error
+		expCalls    int
+		expCanceled int
+	}{
+		{
+			name: "A fast execution should not send hedged executions.",
+			cfg: hedge.Config{
+				Delay:           50 * time.Millisecond,
+				MaxHedgePercent: 100,
+			},
+			execs: &executions{
+				durs: []time.Duration{0, 0},
+				errs: []error{nil, nil},
+			},
+			expErr:   nil,
+			expCalls: 1,
+		},
+		{
+			name: "A slow execution should send a hedged execution and return the first successful result cancelling the others.",
+			cfg: hedge.Config{
+				Delay:           5 * time.Millisecond,
+				MaxHedgePercent: 100,
+			},
+			execs: &executions{
+				durs: []time.Duration{500 * time.Millisecond, 0},
+				errs: []error{nil, nil},
+			},
+			expErr:      nil,
+			expCalls:    2,
+			expCanceled: 1,
+		},
+		{
+			name: "A slow execution should send up to max hedged executions.",
+			cfg: hedge.Config{
+				Delay:           5 * time.Millisecond,
+				MaxHedges:       2,
+				MaxHedgePercent: 200,
+			},
+			execs: &executions{
+				durs: []time.Duration{500 * time.Millisecond, 500 * time.Millisecond, 0},
+				errs: []error{nil, nil, nil},
+			},
+			expErr:      nil,
+			expCalls:    3,
+			expCanceled: 2,
+		},
+		{
+			name: "A slow execution should not send hedged executions if the max hedge percent would be exceeded.",
+			cfg: hedge.Config{
+				Delay:           5 * time.Millisecond,
+				MaxHedgePercent: 10,
+			},
+			execs: &executions{
+				durs: []time.Duration{30 * time.Millisecond, 0},
+				errs: []error{nil, nil},
+			},
+			expErr:   nil,
+			expCalls: 1,
+		},
+		{
+			name: "If all the executions fail it should return the error.",
+			cfg: hedge.Config{
+				Delay:           5 * time.Millisecond,
+				MaxHedgePercent: 100,
+			},
+			execs: &executions{
+				durs: []time.Duration{20 * time.Millisecond, 20 * time.Millisecond},
+				errs: []error{errWanted, errWanted},
+			},
+			expErr:   errWanted,
+			expCalls: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := hedge.New(test.cfg)
+			err := runner.Run(context.TODO(), test.execs.Run)
+
+			// Wait so the cancelled executions finish.
+			time.Sleep(10 * time.Millisecond)
+
+			assert.Equal(test.expErr, err)
+			test.execs.mu.Lock()
+			assert.Equal(test.expCalls, test.execs.calls)
+			assert.Equal(test.expCanceled, test.execs.canceled)
+			test.execs.mu.Unlock()
+		})
+	}
+}
+
+func TestHedgeMaxHedgePercentDoesntAccumulate(t *testing.T) {
+	assert := assert.New(t)
+
+	runner := hedge.New(hedge.Config{
+		Delay:           1 * time.Millisecond,
+		MaxHedgePercent: 10,
+		MaxHedgeBurst:   2,
+	})
+
+	// A calm period with fast executions.
+	for i := 0; i < 100; i++ {
+		err := runner.Run(context.TODO(), func(_ context.Context) error { return nil })
+		assert.NoError(err)
+	}
+
+	// An incident with slow executions should only hedge the burst and the
+	// percent of the incident executions.
+	var mu sync.Mutex
+	calls := 0
+	for i := 0; i < 5; i++ {
+		err := runner.Run(context.TODO(), func(_ context.Context) error {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+		assert.NoError(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(5+2, calls)
+}
+
+func TestHedgeAttempt(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	attempts := map[int]hedge.Attempt{}
+	h := hedge.New(hedge.Config{
+		Delay:           5 * time.Millisecond,
+		MaxHedgePercent: 100,
+	})
+	err := h.Run(context.TODO(), func(ctx context.Context) error {
+		attempt, ok := hedge.AttemptFromContext(ctx)
+		if !ok {
+			return errors.New("missing attempt")
+		}
+
+		mu.Lock()
+		attempts[attempt.Number] = attempt
+		mu.Unlock()
+
+		// Make the first execution slow so it's hedged.
+		if attempt.Number == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	assert.NoError(err)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(map[int]hedge.Attempt{
+		1: {Number: 1, Total: 2},
+		2: {Number: 2, Total: 2},
+	}, attempts)
+}
+
+func TestHedgeLatencyPercentileMeasuresRequestLatency(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	hedged := false
+	h := hedge.New(hedge.Config{
+		Delay:             50 * time.Millisecond,
+		LatencyPercentile: 100,
+		MinimumSamples:    1,
+		MaxHedgePercent:   100,
+	})
+
+	// The first request is won by the hedged execution, the observed
+	// latency should be the one of the request (~50ms) not the one of
+	// the hedged execution (~0ms).
+	err := h.Run(context.TODO(), func(ctx context.Context) error {
+		attempt, _ := hedge.AttemptFromContext(ctx)
+		if attempt.Number == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	assert.NoError(err)
+
+	// A request faster than the observed latency should not be hedged.
+	err = h.Run(context.TODO(), func(ctx context.Context) error {
+		attempt, _ := hedge.AttemptFromContext(ctx)
+		if attempt.Number > 1 {
+			mu.Lock()
+			hedged = true
+			mu.Unlock()
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	assert.NoError(err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.False(hedged)
+}
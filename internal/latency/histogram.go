@@ -0,0 +1,107 @@
+// Package latency has the utilities to measure the latency of the executions
+// used by the runners that adapt based on latency.
+package latency
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// minBucket is the first bucket upper bound.
+	minBucket = 50 * time.Microsecond
+	// bucketGrowthFactor is the factor every bucket grows from the previous one,
+	// this gives a relative error of 10% independently of the latency.
+	bucketGrowthFactor = 1.1
+	// bucketQuantity is the number of buckets, with the min bucket and the
+	// growth factor the last bucket is ~210s.
+	bucketQuantity = 160
+)
+
+// buckets are the upper bounds of the histogram buckets.
+var buckets = func() []time.Duration {
+	bs := make([]time.Duration, bucketQuantity)
+	b := float64(minBucket)
+	for i := range bs {
+		bs[i] = time.Duration(b)
+		b = b * bucketGrowthFactor
+	}
+	return bs
+}()
+
+// Histogram is a streaming latency histogram that knows how to calculate
+// percentiles using bounded memory. It uses exponential buckets so the
+// precision is relative to the measured latency, and it decays the old
+// samples when the max samples are reached, this way the percentiles
+// adapt to the latest latencies.
+type Histogram struct {
+	counts     []float64
+	total      float64
+	maxSamples float64
+	mu         sync.Mutex
+}
+
+// NewHistogram returns a new Histogram, when the histogram reaches the max
+// samples, the weight of all the measured samples will be halved.
+func NewHistogram(maxSamples int) *Histogram {
+	if maxSamples <= 0 {
+		maxSamples = 1000
+	}
+
+	return &Histogram{
+		counts:     make([]float64, bucketQuantity),
+		maxSamples: float64(maxSamples),
+	}
+}
+
+// Observe measures a latency sample.
+func (h *Histogram) Observe(d time.Duration) {
+	i := sort.Search(len(buckets), func(i int) bool { return buckets[i] >= d })
+	if i >= len(buckets) {
+		i = len(buckets) - 1
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Decay the old samples.
+	if h.total >= h.maxSamples {
+		for i := range h.counts {
+			h.counts[i] = h.counts[i] / 2
+		}
+		h.total = h.total / 2
+	}
+
+	h.counts[i]++
+	h.total++
+}
+
+// Samples returns the number of samples the histogram has (after decaying).
+func (h *Histogram) Samples() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return int(h.total)
+}
+
+// Percentile returns the latency of the percentile (0-100) based on the
+// observed samples. If there are no samples it will return 0.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.total <= 0 {
+		return 0
+	}
+
+	target := h.total * p / 100
+	var cumulative float64
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative >= target && cumulative > 0 {
+			return buckets[i]
+		}
+	}
+
+	return buckets[len(buckets)-1]
+}
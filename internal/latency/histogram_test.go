@@ -0,0 +1,69 @@
+package latency_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience/internal/latency"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxSamples int
+		observe    func(h *latency.Histogram)
+		percentile float64
+		expLatency time.Duration
+		expSamples int
+	}{
+		{
+			name:       "A histogram without samples should return 0.",
+			observe:    func(h *latency.Histogram) {},
+			percentile: 99,
+			expLatency: 0,
+			expSamples: 0,
+		},
+		{
+			name: "A histogram should return the percentile of the observed samples.",
+			observe: func(h *latency.Histogram) {
+				for i := 1; i <= 100; i++ {
+					h.Observe(time.Duration(i) * time.Millisecond)
+				}
+			},
+			percentile: 90,
+			expLatency: 90 * time.Millisecond,
+			expSamples: 100,
+		},
+		{
+			name:       "A histogram should decay the old samples when the max samples is reached.",
+			maxSamples: 100,
+			observe: func(h *latency.Histogram) {
+				for i := 0; i < 100; i++ {
+					h.Observe(10 * time.Millisecond)
+				}
+				for i := 0; i < 300; i++ {
+					h.Observe(100 * time.Millisecond)
+				}
+			},
+			percentile: 50,
+			expLatency: 100 * time.Millisecond,
+			expSamples: 100,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			h := latency.NewHistogram(test.maxSamples)
+			test.observe(h)
+
+			// The buckets have a relative error of 10%.
+			got := h.Percentile(test.percentile)
+			assert.InDelta(float64(test.expLatency), float64(got), float64(test.expLatency)*0.1)
+			assert.InDelta(test.expSamples, h.Samples(), 1)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package keyed
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/metrics"
+)
+
+var ctxKeyKey contextKey = "key"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "keyed-ctx-key" + string(c)
+}
+
+// KeyFromContext will get the key of the execution from the context.
+func KeyFromContext(ctx context.Context) (key string, ok bool) {
+	key, ok = ctx.Value(ctxKeyKey).(string)
+	return key, ok
+}
+
+// SetKeyOnContext will set the key of the execution on the context.
+func SetKeyOnContext(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, ctxKeyKey, key)
+}
+
+// KeyFunc returns the key of an execution.
+type KeyFunc func(ctx context.Context) string
+
+// Factory returns the middleware that will be used for the Runner of a key.
+type Factory func(key string) goresilience.Middleware
+
+// Config is the configuration of the keyed runner.
+type Config struct {
+	// Key is the function used to get the key of the execution, by default it
+	// will get the key from the context (set using `SetKeyOnContext`), if the
+	// execution doesn't have a key it will use an empty key.
+	Key KeyFunc
+	// Factory will be used to create the Runner of a key the first time is used.
+	Factory Factory
+	// MaxKeys is the max number of keys that will have a Runner at the same time,
+	// when reached, the least recently used key Runner will be evicted.
+	MaxKeys int
+	// IdleTTL is the time a key Runner can be without being used before being
+	// evicted. If 0 the Runners will not be evicted by idle time.
+	IdleTTL time.Duration
+}
+
+func (c *Config) defaults() {
+	if c.Key == nil {
+		c.Key = func(ctx context.Context) string {
+			key, _ := KeyFromContext(ctx)
+			return key
+		}
+	}
+
+	if c.Factory == nil {
+		c.Factory = func(_ string) goresilience.Middleware {
+			return func(next goresilience.Runner) goresilience.Runner { return next }
+		}
+	}
+
+	if c.MaxKeys <= 0 {
+		c.MaxKeys = 1000
+	}
+
+	if c.IdleTTL < 0 {
+		c.IdleTTL = 0
+	}
+}
+
+// shutdowner is implemented by the Runners that need to be stopped when they
+// are not used anymore (e.g the bulkhead).
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// entry is a key Runner.
+type entry struct {
+	key      string
+	runner   goresilience.Runner
+	lastUsed time.Time
+	running  int  // running is the number of executions using the Runner.
+	evicted  bool // evicted marks the Runner to be shut down when the running executions finish.
+}
+
+type keyed struct {
+	cfg     Config
+	runner  goresilience.Runner
+	entries map[string]*list.Element
+	lru     *list.List // The front is the most recently used.
+	mu      sync.Mutex
+}
+
+// New returns a new keyed runner.
+//
+// The keyed runner will partition the executions by key, every key will
+// have its own independent Runner created lazily with the factory the
+// first time the key is used, for example an independent circuit breaker
+// for every downstream host or tenant.
+//
+// To bound the number of Runners, the least recently used will be evicted
+// when the max keys are reached and the ones that have been idle more than
+// the idle TTL. The metrics of the key Runners will use the key as the ID.
+//
+// The evicted Runners that have a `Shutdown(context.Context) error` method (like
+// the bulkhead) will be shut down when the executions that are using them finish,
+// so the factory should return them as the key Runner to not leak their goroutines.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that uses the Runner returned
+// by keyed.New.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &keyed{
+			cfg:     cfg,
+			runner:  goresilience.SanitizeRunner(next),
+			entries: map[string]*list.Element{},
+			lru:     list.New(),
+		}
+	}
+}
+
+func (k *keyed) Run(ctx context.Context, f goresilience.Func) error {
+	key := k.cfg.Key(ctx)
+	e := k.getEntry(key, time.Now())
+	defer k.release(e)
+
+	// Measure the key Runner with the key as the ID.
+	if metricsRecorder, ok := metrics.RecorderFromContext(ctx); ok {
+		ctx = metrics.SetRecorderOnContext(ctx, metricsRecorder.WithID(key))
+	}
+
+	return e.runner.Run(ctx, f)
+}
+
+// getEntry returns the Runner entry of the key marked as running, if it doesn't exist
+// it will create it.
+func (k *keyed) getEntry(key string, now time.Time) *entry {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.evictIdle(now)
+
+	if elem, ok := k.entries[key]; ok {
+		e := elem.Value.(*entry)
+		e.lastUsed = now
+		e.running++
+		k.lru.MoveToFront(elem)
+		return e
+	}
+
+	// Make room for the new key.
+	for k.lru.Len() >= k.cfg.MaxKeys {
+		k.evict(k.lru.Back())
+	}
+
+	e := &entry{
+		key:      key,
+		runner:   goresilience.SanitizeRunner(k.cfg.Factory(key)(k.runner)),
+		lastUsed: now,
+		running:  1,
+	}
+	k.entries[key] = k.lru.PushFront(e)
+
+	return e
+}
+
+// release will unmark the Runner entry as running, if the Runner has been evicted and
+// is not used anymore it will be shut down.
+func (k *keyed) release(e *entry) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	e.running--
+	if e.evicted && e.running == 0 {
+		shutdown(e.runner)
+	}
+}
+
+// evictIdle will evict the Runners that have been idle more than the TTL.
+func (k *keyed) evictIdle(now time.Time) {
+	if k.cfg.IdleTTL == 0 {
+		return
+	}
+
+	for elem := k.lru.Back(); elem != nil; elem = k.lru.Back() {
+		if now.Sub(elem.Value.(*entry).lastUsed) <= k.cfg.IdleTTL {
+			return
+		}
+		k.evict(elem)
+	}
+}
+
+func (k *keyed) evict(elem *list.Element) {
+	e := k.lru.Remove(elem).(*entry)
+	delete(k.entries, e.key)
+
+	e.evicted = true
+	if e.running == 0 {
+		shutdown(e.runner)
+	}
+}
+
+// shutdown will shut down the Runner in background if it can be shut down.
+func shutdown(r goresilience.Runner) {
+	s, ok := r.(shutdowner)
+	if !ok {
+		return
+	}
+
+	// The Runner is not used anymore so there is nobody waiting for the result.
+	go s.Shutdown(context.Background())
+}
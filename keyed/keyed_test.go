@@ -0,0 +1,203 @@
+package keyed_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/keyed"
+)
+
+// counterFactory creates key Runners that count the executions of the key.
+type counterFactory struct {
+	created    map[string]int
+	executions map[string]int
+	mu         sync.Mutex
+}
+
+func newCounterFactory() *counterFactory {
+	return &counterFactory{
+		created:    map[string]int{},
+		executions: map[string]int{},
+	}
+}
+
+func (c *counterFactory) Factory(key string) goresilience.Middleware {
+	c.mu.Lock()
+	c.created[key]++
+	c.mu.Unlock()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			c.mu.Lock()
+			c.executions[key]++
+			c.mu.Unlock()
+			return next.Run(ctx, f)
+		})
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           keyed.Config
+		keys          []string
+		sleep         time.Duration
+		expCreated    map[string]int
+		expExecutions map[string]int
+	}{
+		{
+			name: "Every key should have its own Runner.",
+			cfg:  keyed.Config{},
+			keys: []string{"a", "b", "a", "c", "a", "b"},
+			expCreated: map[string]int{
+				"a": 1,
+				"b": 1,
+				"c": 1,
+			},
+			expExecutions: map[string]int{
+				"a": 3,
+				"b": 2,
+				"c": 1,
+			},
+		},
+		{
+			name: "When the max keys are reached the least recently used key Runner should be evicted.",
+			cfg: keyed.Config{
+				MaxKeys: 2,
+			},
+			keys: []string{"a", "b", "a", "c", "a", "b"},
+			expCreated: map[string]int{
+				"a": 1,
+				"b": 2,
+				"c": 1,
+			},
+			expExecutions: map[string]int{
+				"a": 3,
+				"b": 2,
+				"c": 1,
+			},
+		},
+		{
+			name: "The idle key Runners should be evicted.",
+			cfg: keyed.Config{
+				IdleTTL: 1 * time.Millisecond,
+			},
+			keys:  []string{"a", "b", "a"},
+			sleep: 5 * time.Millisecond,
+			expCreated: map[string]int{
+				"a": 2,
+				"b": 1,
+			},
+			expExecutions: map[string]int{
+				"a": 2,
+				"b": 1,
+			},
+		},
+		{
+			name: "A custom key function should be used to get the keys.",
+			cfg: keyed.Config{
+				Key: func(_ context.Context) string { return "custom" },
+			},
+			keys: []string{"a", "b", "c"},
+			expCreated: map[string]int{
+				"custom": 1,
+			},
+			expExecutions: map[string]int{
+				"custom": 3,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cf := newCounterFactory()
+			test.cfg.Factory = cf.Factory
+			runner := keyed.New(test.cfg)
+
+			for _, key := range test.keys {
+				ctx := keyed.SetKeyOnContext(context.TODO(), key)
+				err := runner.Run(ctx, func(_ context.Context) error { return nil })
+				assert.NoError(err)
+				time.Sleep(test.sleep)
+			}
+
+			assert.Equal(test.expCreated, cf.created)
+			assert.Equal(test.expExecutions, cf.executions)
+		})
+	}
+}
+
+// shutdownRunner is a key Runner that can be shut down.
+type shutdownRunner struct {
+	goresilience.Runner
+	shutdown chan struct{}
+}
+
+func (s *shutdownRunner) Shutdown(_ context.Context) error {
+	close(s.shutdown)
+	return nil
+}
+
+func TestKeyedShutdownEvicted(t *testing.T) {
+	assert := assert.New(t)
+
+	runners := map[string]*shutdownRunner{}
+	runner := keyed.New(keyed.Config{
+		MaxKeys: 1,
+		Factory: func(key string) goresilience.Middleware {
+			return func(next goresilience.Runner) goresilience.Runner {
+				runners[key] = &shutdownRunner{Runner: next, shutdown: make(chan struct{})}
+				return runners[key]
+			}
+		},
+	})
+
+	// Key a is evicted while running.
+	running := make(chan struct{})
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		ctx := keyed.SetKeyOnContext(context.TODO(), "a")
+		runner.Run(ctx, func(_ context.Context) error {
+			close(running)
+			<-release
+			return nil
+		})
+		close(finished)
+	}()
+	<-running
+	ctx := keyed.SetKeyOnContext(context.TODO(), "b")
+	err := runner.Run(ctx, func(_ context.Context) error { return nil })
+	assert.NoError(err)
+
+	// The evicted Runner should be shut down only when is not used anymore.
+	select {
+	case <-runners["a"].shutdown:
+		assert.Fail("the evicted runner should not be shut down while is running")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-finished
+	select {
+	case <-runners["a"].shutdown:
+	case <-time.After(100 * time.Millisecond):
+		assert.Fail("the evicted runner should be shut down")
+	}
+
+	// Key b is evicted without running.
+	ctx = keyed.SetKeyOnContext(context.TODO(), "c")
+	err = runner.Run(ctx, func(_ context.Context) error { return nil })
+	assert.NoError(err)
+	select {
+	case <-runners["b"].shutdown:
+	case <-time.After(100 * time.Millisecond):
+		assert.Fail("the evicted runner should be shut down")
+	}
+}
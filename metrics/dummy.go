@@ -7,17 +7,34 @@ var Dummy Recorder = &dummy{}
 
 type dummy struct{}
 
-func (d *dummy) WithID(id string) Recorder                          { return d }
-func (dummy) ObserveCommandExecution(start time.Time, success bool) {}
-func (dummy) IncRetry()                                             {}
-func (dummy) IncTimeout()                                           {}
-func (dummy) IncBulkheadQueued()                                    {}
-func (dummy) IncBulkheadProcessed()                                 {}
-func (dummy) IncBulkheadTimeout()                                   {}
-func (dummy) IncCircuitbreakerState(state string)                   {}
-func (dummy) IncChaosInjectedFailure(kind string)                   {}
-func (dummy) SetConcurrencyLimitInflightExecutions(q int)           {}
-func (dummy) SetConcurrencyLimitExecutingExecutions(q int)          {}
-func (dummy) IncConcurrencyLimitResult(result string)               {}
-func (dummy) SetConcurrencyLimitLimiterLimit(limit int)             {}
-func (dummy) ObserveConcurrencyLimitQueuedTime(start time.Time)     {}
+func (d *dummy) WithID(id string) Recorder                                   { return d }
+func (dummy) ObserveCommandExecution(start time.Time, success bool)          {}
+func (dummy) IncRetry()                                                      {}
+func (dummy) IncTimeout()                                                    {}
+func (dummy) IncBulkheadQueued()                                             {}
+func (dummy) IncBulkheadProcessed()                                          {}
+func (dummy) IncBulkheadTimeout()                                            {}
+func (dummy) IncCircuitbreakerState(state string)                            {}
+func (dummy) IncChaosInjectedFailure(kind string)                            {}
+func (dummy) SetConcurrencyLimitInflightExecutions(q int)                    {}
+func (dummy) SetConcurrencyLimitExecutingExecutions(q int)                   {}
+func (dummy) IncConcurrencyLimitResult(result string)                        {}
+func (dummy) SetConcurrencyLimitLimiterLimit(limit int)                      {}
+func (dummy) ObserveConcurrencyLimitQueuedTime(start time.Time)              {}
+func (dummy) IncFallback(success bool)                                       {}
+func (dummy) IncHedge()                                                      {}
+func (dummy) IncHedgeWon()                                                   {}
+func (dummy) IncRateLimitResult(allowed bool)                                {}
+func (dummy) ObserveRateLimitWaitTime(start time.Time)                       {}
+func (dummy) IncCoalesceExecution(shared bool)                               {}
+func (dummy) IncPanicRecovered()                                             {}
+func (dummy) SetRetryBudgetTokens(tokens float64)                            {}
+func (dummy) IncTimeoutContextCanceled()                                     {}
+func (dummy) SetTimeoutAdaptiveTimeout(timeout time.Duration)                {}
+func (dummy) SetTimeoutAbandonedExecutions(executions int)                   {}
+func (dummy) IncBulkheadCanceled()                                           {}
+func (dummy) IncConcurrencyLimitCanceled()                                   {}
+func (dummy) SetConcurrencyLimitTenantQueuedExecutions(tenant string, q int) {}
+func (dummy) IncConcurrencyLimitTenantRejected(tenant string)                {}
+func (dummy) SetBulkheadWorkers(workers int)                                 {}
+func (dummy) SetBulkheadActiveWorkers(workers int)                           {}
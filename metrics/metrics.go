@@ -35,4 +35,38 @@ type Recorder interface {
 	SetConcurrencyLimitLimiterLimit(limit int)
 	// ObserveConcurrencyLimitQueuedTime will measure the duration of a function waiting on a queue until it's executed.
 	ObserveConcurrencyLimitQueuedTime(start time.Time)
+	// IncFallback increments the number of fallbacks executed and if they were successful.
+	IncFallback(success bool)
+	// IncHedge increments the number of hedged executions sent.
+	IncHedge()
+	// IncHedgeWon increments the number of hedged executions that won to the original execution.
+	IncHedgeWon()
+	// IncRateLimitResult increments the number of executions allowed or limited by the rate limiter.
+	IncRateLimitResult(allowed bool)
+	// ObserveRateLimitWaitTime will measure the duration of a function waiting to be allowed by the rate limiter.
+	ObserveRateLimitWaitTime(start time.Time)
+	// IncCoalesceExecution increments the number of executions coalesced, if shared the execution result was shared from another execution.
+	IncCoalesceExecution(shared bool)
+	// IncPanicRecovered increments the number of panics recovered.
+	IncPanicRecovered()
+	// SetRetryBudgetTokens sets the tokens available on the retry budget.
+	SetRetryBudgetTokens(tokens float64)
+	// IncTimeoutContextCanceled will increment the number of executions canceled by the parent context before the timeout.
+	IncTimeoutContextCanceled()
+	// SetTimeoutAdaptiveTimeout sets the current timeout calculated by the adaptive timeout.
+	SetTimeoutAdaptiveTimeout(timeout time.Duration)
+	// SetTimeoutAbandonedExecutions sets the number of executions abandoned by the timeout that are still running.
+	SetTimeoutAbandonedExecutions(executions int)
+	// IncBulkheadCanceled increments the number of Funcs that stopped waiting to execute due to the context cancellation.
+	IncBulkheadCanceled()
+	// IncConcurrencyLimitCanceled increments the number of Funcs that stopped waiting on the queue due to the context cancellation.
+	IncConcurrencyLimitCanceled()
+	// SetConcurrencyLimitTenantQueuedExecutions sets the number of queued executions of a tenant at a given moment.
+	SetConcurrencyLimitTenantQueuedExecutions(tenant string, q int)
+	// IncConcurrencyLimitTenantRejected increments the number of executions of a tenant rejected due to its queue being full.
+	IncConcurrencyLimitTenantRejected(tenant string)
+	// SetBulkheadWorkers sets the number of workers (or permits on semaphore mode) of the bulkhead.
+	SetBulkheadWorkers(workers int)
+	// SetBulkheadActiveWorkers sets the number of workers (or permits on semaphore mode) of the bulkhead executing Funcs.
+	SetBulkheadActiveWorkers(workers int)
 }
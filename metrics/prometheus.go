@@ -17,6 +17,11 @@ var (
 	promCBSubsystem               = "circuitbreaker"
 	promChaosSubsystem            = "chaos"
 	promConcurrencyLimitSubsystem = "concurrencylimit"
+	promFallbackSubsystem         = "fallback"
+	promHedgeSubsystem            = "hedge"
+	promRateLimitSubsystem        = "ratelimit"
+	promCoalesceSubsystem         = "coalesce"
+	promRecoverySubsystem         = "recovery"
 )
 
 type prometheusRec struct {
@@ -34,6 +39,23 @@ type prometheusRec struct {
 	concurrencyLimitResult         *prometheus.CounterVec
 	concurrencyLimitLimit          *prometheus.GaugeVec
 	concurrencyLimitQueuedDuration *prometheus.HistogramVec
+	fallbackExecutions             *prometheus.CounterVec
+	hedgeHedges                    *prometheus.CounterVec
+	hedgeWins                      *prometheus.CounterVec
+	rateLimitResults               *prometheus.CounterVec
+	rateLimitWaitDuration          *prometheus.HistogramVec
+	coalesceExecutions             *prometheus.CounterVec
+	recoveryPanics                 *prometheus.CounterVec
+	retryBudgetTokens              *prometheus.GaugeVec
+	timeoutCanceled                *prometheus.CounterVec
+	timeoutAdaptiveTimeout         *prometheus.GaugeVec
+	timeoutAbandoned               *prometheus.GaugeVec
+	bulkCanceled                   *prometheus.CounterVec
+	concurrencyLimitCanceled       *prometheus.CounterVec
+	concurrencyLimitTenantQueued   *prometheus.GaugeVec
+	concurrencyLimitTenantRejected *prometheus.CounterVec
+	bulkheadWorkers                *prometheus.GaugeVec
+	bulkheadActiveWorkers          *prometheus.GaugeVec
 
 	id  string
 	reg prometheus.Registerer
@@ -65,6 +87,23 @@ func (p prometheusRec) WithID(id string) Recorder {
 		concurrencyLimitResult:         p.concurrencyLimitResult,
 		concurrencyLimitLimit:          p.concurrencyLimitLimit,
 		concurrencyLimitQueuedDuration: p.concurrencyLimitQueuedDuration,
+		fallbackExecutions:             p.fallbackExecutions,
+		hedgeHedges:                    p.hedgeHedges,
+		hedgeWins:                      p.hedgeWins,
+		rateLimitResults:               p.rateLimitResults,
+		rateLimitWaitDuration:          p.rateLimitWaitDuration,
+		coalesceExecutions:             p.coalesceExecutions,
+		recoveryPanics:                 p.recoveryPanics,
+		retryBudgetTokens:              p.retryBudgetTokens,
+		timeoutCanceled:                p.timeoutCanceled,
+		timeoutAdaptiveTimeout:         p.timeoutAdaptiveTimeout,
+		timeoutAbandoned:               p.timeoutAbandoned,
+		bulkCanceled:                   p.bulkCanceled,
+		concurrencyLimitCanceled:       p.concurrencyLimitCanceled,
+		concurrencyLimitTenantQueued:   p.concurrencyLimitTenantQueued,
+		concurrencyLimitTenantRejected: p.concurrencyLimitTenantRejected,
+		bulkheadWorkers:                p.bulkheadWorkers,
+		bulkheadActiveWorkers:          p.bulkheadActiveWorkers,
 
 		id:  id,
 		reg: p.reg,
@@ -165,6 +204,126 @@ func (p *prometheusRec) registerMetrics() {
 		Buckets:   []float64{.001, .005, .01, .015, .025, 0.05, 0.1, 0.2, 0.5, 1, 2.5, 5, 10},
 	}, []string{"id"})
 
+	p.fallbackExecutions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promFallbackSubsystem,
+		Name:      "executions_total",
+		Help:      "Total number of fallbacks executed by the fallback runner.",
+	}, []string{"id", "success"})
+
+	p.hedgeHedges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promHedgeSubsystem,
+		Name:      "hedges_total",
+		Help:      "Total number of hedged executions sent by the hedge runner.",
+	}, []string{"id"})
+
+	p.hedgeWins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promHedgeSubsystem,
+		Name:      "hedges_won_total",
+		Help:      "Total number of hedged executions that finished before the original execution.",
+	}, []string{"id"})
+
+	p.rateLimitResults = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promRateLimitSubsystem,
+		Name:      "results_total",
+		Help:      "Total number of executions allowed or limited by the rate limit runner.",
+	}, []string{"id", "allowed"})
+
+	p.rateLimitWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: promNamespace,
+		Subsystem: promRateLimitSubsystem,
+		Name:      "wait_duration_seconds",
+		Help:      "The duration of the command waiting to be allowed by the rate limiter.",
+		Buckets:   []float64{.001, .005, .01, .015, .025, 0.05, 0.1, 0.2, 0.5, 1, 2.5, 5, 10},
+	}, []string{"id"})
+
+	p.coalesceExecutions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promCoalesceSubsystem,
+		Name:      "executions_total",
+		Help:      "Total number of executions made or shared by the coalesce runner.",
+	}, []string{"id", "shared"})
+
+	p.recoveryPanics = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promRecoverySubsystem,
+		Name:      "panics_total",
+		Help:      "Total number of panics recovered.",
+	}, []string{"id"})
+
+	p.retryBudgetTokens = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: promNamespace,
+		Subsystem: promRetrySubsystem,
+		Name:      "budget_tokens",
+		Help:      "The number of tokens available on the retry budget.",
+	}, []string{"id"})
+
+	p.timeoutCanceled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promTimeoutSubsystem,
+		Name:      "context_canceled_total",
+		Help:      "Total number of executions canceled by the parent context before the timeout.",
+	}, []string{"id"})
+
+	p.timeoutAdaptiveTimeout = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: promNamespace,
+		Subsystem: promTimeoutSubsystem,
+		Name:      "adaptive_timeout_seconds",
+		Help:      "The current timeout calculated by the adaptive timeout.",
+	}, []string{"id"})
+
+	p.timeoutAbandoned = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: promNamespace,
+		Subsystem: promTimeoutSubsystem,
+		Name:      "abandoned_executions",
+		Help:      "The number of executions abandoned by the timeout that are still running.",
+	}, []string{"id"})
+
+	p.bulkCanceled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promBulkheadSubsystem,
+		Name:      "canceled_total",
+		Help:      "Total number of executions that stopped waiting to be executed due to the context cancellation.",
+	}, []string{"id"})
+
+	p.concurrencyLimitCanceled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promConcurrencyLimitSubsystem,
+		Name:      "canceled_total",
+		Help:      "Total number of executions that stopped waiting on the queue due to the context cancellation.",
+	}, []string{"id"})
+
+	p.concurrencyLimitTenantQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: promNamespace,
+		Subsystem: promConcurrencyLimitSubsystem,
+		Name:      "tenant_queued_executions",
+		Help:      "The number of queued executions by tenant.",
+	}, []string{"id", "tenant"})
+
+	p.concurrencyLimitTenantRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: promNamespace,
+		Subsystem: promConcurrencyLimitSubsystem,
+		Name:      "tenant_rejected_total",
+		Help:      "Total number of executions rejected by tenant due to the tenant queue being full.",
+	}, []string{"id", "tenant"})
+
+	p.bulkheadWorkers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: promNamespace,
+		Subsystem: promBulkheadSubsystem,
+		Name:      "workers",
+		Help:      "The number of workers of the bulkhead.",
+	}, []string{"id"})
+
+	p.bulkheadActiveWorkers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: promNamespace,
+		Subsystem: promBulkheadSubsystem,
+		Name:      "active_workers",
+		Help:      "The number of workers of the bulkhead executing.",
+	}, []string{"id"})
+
 	p.reg.MustRegister(p.cmdExecutionDuration,
 		p.retryRetries,
 		p.timeoutTimeouts,
@@ -178,6 +337,23 @@ func (p *prometheusRec) registerMetrics() {
 		p.concurrencyLimitResult,
 		p.concurrencyLimitLimit,
 		p.concurrencyLimitQueuedDuration,
+		p.fallbackExecutions,
+		p.hedgeHedges,
+		p.hedgeWins,
+		p.rateLimitResults,
+		p.rateLimitWaitDuration,
+		p.coalesceExecutions,
+		p.recoveryPanics,
+		p.retryBudgetTokens,
+		p.timeoutCanceled,
+		p.timeoutAdaptiveTimeout,
+		p.timeoutAbandoned,
+		p.bulkCanceled,
+		p.concurrencyLimitCanceled,
+		p.concurrencyLimitTenantQueued,
+		p.concurrencyLimitTenantRejected,
+		p.bulkheadWorkers,
+		p.bulkheadActiveWorkers,
 	)
 }
 
@@ -234,3 +410,72 @@ func (p prometheusRec) ObserveConcurrencyLimitQueuedTime(start time.Time) {
 	secs := time.Since(start).Seconds()
 	p.concurrencyLimitQueuedDuration.WithLabelValues(p.id).Observe(secs)
 }
+
+func (p prometheusRec) IncFallback(success bool) {
+	p.fallbackExecutions.WithLabelValues(p.id, fmt.Sprintf("%t", success)).Inc()
+}
+
+func (p prometheusRec) IncHedge() {
+	p.hedgeHedges.WithLabelValues(p.id).Inc()
+}
+
+func (p prometheusRec) IncHedgeWon() {
+	p.hedgeWins.WithLabelValues(p.id).Inc()
+}
+
+func (p prometheusRec) IncRateLimitResult(allowed bool) {
+	p.rateLimitResults.WithLabelValues(p.id, fmt.Sprintf("%t", allowed)).Inc()
+}
+
+func (p prometheusRec) ObserveRateLimitWaitTime(start time.Time) {
+	secs := time.Since(start).Seconds()
+	p.rateLimitWaitDuration.WithLabelValues(p.id).Observe(secs)
+}
+
+func (p prometheusRec) IncCoalesceExecution(shared bool) {
+	p.coalesceExecutions.WithLabelValues(p.id, fmt.Sprintf("%t", shared)).Inc()
+}
+
+func (p prometheusRec) IncPanicRecovered() {
+	p.recoveryPanics.WithLabelValues(p.id).Inc()
+}
+
+func (p prometheusRec) SetRetryBudgetTokens(tokens float64) {
+	p.retryBudgetTokens.WithLabelValues(p.id).Set(tokens)
+}
+
+func (p prometheusRec) IncTimeoutContextCanceled() {
+	p.timeoutCanceled.WithLabelValues(p.id).Inc()
+}
+
+func (p prometheusRec) SetTimeoutAdaptiveTimeout(timeout time.Duration) {
+	p.timeoutAdaptiveTimeout.WithLabelValues(p.id).Set(timeout.Seconds())
+}
+
+func (p prometheusRec) SetTimeoutAbandonedExecutions(executions int) {
+	p.timeoutAbandoned.WithLabelValues(p.id).Set(float64(executions))
+}
+
+func (p prometheusRec) IncBulkheadCanceled() {
+	p.bulkCanceled.WithLabelValues(p.id).Inc()
+}
+
+func (p prometheusRec) IncConcurrencyLimitCanceled() {
+	p.concurrencyLimitCanceled.WithLabelValues(p.id).Inc()
+}
+
+func (p prometheusRec) SetConcurrencyLimitTenantQueuedExecutions(tenant string, q int) {
+	p.concurrencyLimitTenantQueued.WithLabelValues(p.id, tenant).Set(float64(q))
+}
+
+func (p prometheusRec) IncConcurrencyLimitTenantRejected(tenant string) {
+	p.concurrencyLimitTenantRejected.WithLabelValues(p.id, tenant).Inc()
+}
+
+func (p prometheusRec) SetBulkheadWorkers(workers int) {
+	p.bulkheadWorkers.WithLabelValues(p.id).Set(float64(workers))
+}
+
+func (p prometheusRec) SetBulkheadActiveWorkers(workers int) {
+	p.bulkheadActiveWorkers.WithLabelValues(p.id).Set(float64(workers))
+}
@@ -4,6 +4,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strconv"
 	"testing"
 	"time"
 
@@ -175,6 +177,193 @@ func TestPrometheus(t *testing.T) {
 				`goresilience_concurrencylimit_result_total{id="test2",result="ignore"} 1`,
 			},
 		},
+		{
+			name: "Recording fallback metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.IncFallback(true)
+				m1.IncFallback(true)
+				m1.IncFallback(false)
+				m2.IncFallback(false)
+			},
+			expMetrics: []string{
+				`goresilience_fallback_executions_total{id="test",success="false"} 1`,
+				`goresilience_fallback_executions_total{id="test",success="true"} 2`,
+				`goresilience_fallback_executions_total{id="test2",success="false"} 1`,
+			},
+		},
+		{
+			name: "Recording hedge metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.IncHedge()
+				m1.IncHedge()
+				m1.IncHedgeWon()
+				m2.IncHedge()
+			},
+			expMetrics: []string{
+				`goresilience_hedge_hedges_total{id="test"} 2`,
+				`goresilience_hedge_hedges_total{id="test2"} 1`,
+				`goresilience_hedge_hedges_won_total{id="test"} 1`,
+			},
+		},
+		{
+			name: "Recording rate limit metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.IncRateLimitResult(true)
+				m1.IncRateLimitResult(true)
+				m1.IncRateLimitResult(false)
+				m2.IncRateLimitResult(false)
+				m1.ObserveRateLimitWaitTime(now.Add(-20 * time.Millisecond))
+			},
+			expMetrics: []string{
+				`goresilience_ratelimit_results_total{allowed="false",id="test"} 1`,
+				`goresilience_ratelimit_results_total{allowed="true",id="test"} 2`,
+				`goresilience_ratelimit_results_total{allowed="false",id="test2"} 1`,
+				`goresilience_ratelimit_wait_duration_seconds_count{id="test"} 1`,
+			},
+		},
+		{
+			name: "Recording coalesce metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.IncCoalesceExecution(false)
+				m1.IncCoalesceExecution(true)
+				m1.IncCoalesceExecution(true)
+				m2.IncCoalesceExecution(false)
+			},
+			expMetrics: []string{
+				`goresilience_coalesce_executions_total{id="test",shared="false"} 1`,
+				`goresilience_coalesce_executions_total{id="test",shared="true"} 2`,
+				`goresilience_coalesce_executions_total{id="test2",shared="false"} 1`,
+			},
+		},
+		{
+			name: "Recording recovery metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.IncPanicRecovered()
+				m1.IncPanicRecovered()
+				m2.IncPanicRecovered()
+			},
+			expMetrics: []string{
+				`goresilience_recovery_panics_total{id="test"} 2`,
+				`goresilience_recovery_panics_total{id="test2"} 1`,
+			},
+		},
+		{
+			name: "Recording retry budget metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.SetRetryBudgetTokens(7.5)
+				m2.SetRetryBudgetTokens(2)
+			},
+			expMetrics: []string{
+				`goresilience_retry_budget_tokens{id="test"} 7.5`,
+				`goresilience_retry_budget_tokens{id="test2"} 2`,
+			},
+		},
+		{
+			name: "Recording timeout cancellation metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.IncTimeoutContextCanceled()
+				m1.IncTimeoutContextCanceled()
+				m2.IncTimeoutContextCanceled()
+			},
+			expMetrics: []string{
+				`goresilience_timeout_context_canceled_total{id="test"} 2`,
+				`goresilience_timeout_context_canceled_total{id="test2"} 1`,
+			},
+		},
+		{
+			name: "Recording adaptive timeout metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.SetTimeoutAdaptiveTimeout(250 * time.Millisecond)
+				m2.SetTimeoutAdaptiveTimeout(2 * time.Second)
+			},
+			expMetrics: []string{
+				`goresilience_timeout_adaptive_timeout_seconds{id="test"} 0.25`,
+				`goresilience_timeout_adaptive_timeout_seconds{id="test2"} 2`,
+			},
+		},
+		{
+			name: "Recording timeout abandoned executions metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.SetTimeoutAbandonedExecutions(12)
+				m2.SetTimeoutAbandonedExecutions(3)
+			},
+			expMetrics: []string{
+				`goresilience_timeout_abandoned_executions{id="test"} 12`,
+				`goresilience_timeout_abandoned_executions{id="test2"} 3`,
+			},
+		},
+		{
+			name: "Recording canceled executions metrics should expose the metrics.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.IncBulkheadCanceled()
+				m1.IncBulkheadCanceled()
+				m2.IncBulkheadCanceled()
+				m1.IncConcurrencyLimitCanceled()
+			},
+			expMetrics: []string{
+				`goresilience_bulkhead_canceled_total{id="test"} 2`,
+				`goresilience_bulkhead_canceled_total{id="test2"} 1`,
+				`goresilience_concurrencylimit_canceled_total{id="test"} 1`,
+			},
+		},
+		{
+			name: "Recording concurrency limit tenant metrics should expose the metrics by tenant.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.SetConcurrencyLimitTenantQueuedExecutions("tenant1", 5)
+				m1.SetConcurrencyLimitTenantQueuedExecutions("tenant2", 3)
+				m2.SetConcurrencyLimitTenantQueuedExecutions("tenant1", 1)
+				m1.IncConcurrencyLimitTenantRejected("tenant1")
+				m1.IncConcurrencyLimitTenantRejected("tenant1")
+				m2.IncConcurrencyLimitTenantRejected("tenant2")
+			},
+			expMetrics: []string{
+				`goresilience_concurrencylimit_tenant_queued_executions{id="test",tenant="tenant1"} 5`,
+				`goresilience_concurrencylimit_tenant_queued_executions{id="test",tenant="tenant2"} 3`,
+				`goresilience_concurrencylimit_tenant_queued_executions{id="test2",tenant="tenant1"} 1`,
+				`goresilience_concurrencylimit_tenant_rejected_total{id="test",tenant="tenant1"} 2`,
+				`goresilience_concurrencylimit_tenant_rejected_total{id="test2",tenant="tenant2"} 1`,
+			},
+		},
+		{
+			name: "Recording bulkhead workers metrics should expose the gauges of the workers.",
+			recordMetrics: func(m metrics.Recorder) {
+				m1 := m.WithID("test")
+				m2 := m.WithID("test2")
+				m1.SetBulkheadWorkers(10)
+				m1.SetBulkheadWorkers(15)
+				m2.SetBulkheadWorkers(5)
+				m1.SetBulkheadActiveWorkers(7)
+				m2.SetBulkheadActiveWorkers(2)
+			},
+			expMetrics: []string{
+				`goresilience_bulkhead_workers{id="test"} 15`,
+				`goresilience_bulkhead_workers{id="test2"} 5`,
+				`goresilience_bulkhead_active_workers{id="test"} 7`,
+				`goresilience_bulkhead_active_workers{id="test2"} 2`,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -204,3 +393,29 @@ func TestPrometheus(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusRateLimitWaitTime(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := prometheus.NewRegistry()
+	p := metrics.NewPrometheusRecorder(reg).WithID("test")
+	p.ObserveRateLimitWaitTime(time.Now().Add(-20 * time.Millisecond))
+
+	// Get the metrics handler and serve.
+	h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	h.ServeHTTP(rec, req)
+	body, _ := ioutil.ReadAll(rec.Result().Body)
+
+	// The observed wait time depends on the time passed since the start, so
+	// we can only check that at least the waited time has been measured.
+	assert.Contains(string(body), `goresilience_ratelimit_wait_duration_seconds_count{id="test"} 1`)
+	sumRegexp := regexp.MustCompile(`goresilience_ratelimit_wait_duration_seconds_sum{id="test"} (\S+)`)
+	match := sumRegexp.FindStringSubmatch(string(body))
+	if assert.Len(match, 2) {
+		sum, err := strconv.ParseFloat(match[1], 64)
+		assert.NoError(err)
+		assert.True(sum >= 0.02)
+	}
+}
@@ -0,0 +1,45 @@
+package goresilience
+
+import (
+	"context"
+)
+
+// Priority is the priority class of an execution, the runners that queue
+// executions (like the bulkhead or the concurrencylimit executors) will
+// execute first the executions with higher priority and when congested will
+// reject first the executions with lower priority.
+type Priority int
+
+const (
+	// PrioritySheddable is the priority of the executions that can be dropped
+	// first when there is congestion (e.g: batch jobs).
+	PrioritySheddable Priority = -1
+	// PriorityNormal is the default priority of the executions.
+	PriorityNormal Priority = 0
+	// PriorityCritical is the priority of the executions that should be executed
+	// before the others (e.g: user facing requests).
+	PriorityCritical Priority = 1
+)
+
+var ctxPriorityKey contextKey = "priority"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "goresilience-ctx-key" + string(c)
+}
+
+// PriorityFromContext will get the priority of the execution from the context,
+// if the context doesn't have a priority it will return PriorityNormal.
+func PriorityFromContext(ctx context.Context) Priority {
+	p, ok := ctx.Value(ctxPriorityKey).(Priority)
+	if !ok {
+		return PriorityNormal
+	}
+	return p
+}
+
+// SetPriorityOnContext will set the priority of the execution on the context.
+func SetPriorityOnContext(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, ctxPriorityKey, p)
+}
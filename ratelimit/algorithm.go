@@ -0,0 +1,21 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+// Algorithm knows if an execution should be allowed based on the rate of the
+// allowed executions.
+//
+// The algorithms receive the time of the decision so they don't depend on the
+// system clock.
+type Algorithm interface {
+	// Reserve will try to reserve an execution at the given time, it returns how much
+	// the execution needs to wait to be allowed. If the execution can't be allowed
+	// waiting less than the max wait duration it will return false and the execution
+	// will not count for the rate.
+	Reserve(now time.Time, maxWait time.Duration) (wait time.Duration, ok bool)
+	// Cancel will cancel a reservation that was allowed at the received time
+	// (reservation time + wait) and finally has not been executed.
+	Cancel(allowedAt time.Time)
+}
@@ -0,0 +1,159 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience/ratelimit"
+)
+
+// reservation is a reservation made to the algorithm at a time of the fake clock.
+type reservation struct {
+	at      time.Duration // The time since the start of the fake clock.
+	maxWait time.Duration
+	cancel  bool
+	expWait time.Duration
+	expOK   bool
+}
+
+func TestAlgorithms(t *testing.T) {
+	// Start aligned with the windows.
+	start := time.Unix(1000, 0)
+
+	tests := []struct {
+		name         string
+		algorithm    func() ratelimit.Algorithm
+		reservations []reservation
+	}{
+		{
+			name: "Token bucket should allow bursts and refill the tokens at a constant rate.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{Rate: 10, Burst: 2})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 0, expOK: true},
+				{at: 0, expOK: false},
+				{at: 0, maxWait: 1 * time.Second, expWait: 100 * time.Millisecond, expOK: true},
+				{at: 0, maxWait: 150 * time.Millisecond, expOK: false},
+				{at: 300 * time.Millisecond, expOK: true},
+				{at: 300 * time.Millisecond, expOK: true},
+				{at: 300 * time.Millisecond, expOK: false},
+			},
+		},
+		{
+			name: "Token bucket should return the tokens of the cancelled reservations.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewTokenBucket(ratelimit.TokenBucketConfig{Rate: 10, Burst: 1})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 0, maxWait: 1 * time.Second, expWait: 100 * time.Millisecond, expOK: true, cancel: true},
+				{at: 0, maxWait: 1 * time.Second, expWait: 100 * time.Millisecond, expOK: true},
+			},
+		},
+		{
+			name: "GCRA should allow bursts and space the executions at a constant rate.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewGCRA(ratelimit.GCRAConfig{Rate: 10, Burst: 2})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 0, expOK: true},
+				{at: 0, expOK: false},
+				{at: 0, maxWait: 1 * time.Second, expWait: 100 * time.Millisecond, expOK: true},
+				{at: 0, maxWait: 150 * time.Millisecond, expOK: false},
+				{at: 300 * time.Millisecond, expOK: true},
+				{at: 300 * time.Millisecond, expOK: true},
+				{at: 300 * time.Millisecond, expOK: false},
+			},
+		},
+		{
+			name: "GCRA should return the cancelled reservations.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewGCRA(ratelimit.GCRAConfig{Rate: 10, Burst: 1})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 0, maxWait: 1 * time.Second, expWait: 100 * time.Millisecond, expOK: true, cancel: true},
+				{at: 0, maxWait: 1 * time.Second, expWait: 100 * time.Millisecond, expOK: true},
+			},
+		},
+		{
+			name: "Sliding log should allow the executions only if there are less than the limit on the window.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewSlidingLog(ratelimit.SlidingLogConfig{Limit: 2, Window: 1 * time.Second})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 100 * time.Millisecond, expOK: true},
+				{at: 200 * time.Millisecond, expOK: false},
+				{at: 200 * time.Millisecond, maxWait: 1 * time.Second, expWait: 800 * time.Millisecond, expOK: true},
+				{at: 500 * time.Millisecond, maxWait: 1 * time.Second, expWait: 600 * time.Millisecond, expOK: true},
+				{at: 1050 * time.Millisecond, expOK: false},
+				{at: 2100 * time.Millisecond, expOK: true},
+			},
+		},
+		{
+			name: "Sliding log should remove the cancelled reservations.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewSlidingLog(ratelimit.SlidingLogConfig{Limit: 2, Window: 1 * time.Second})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 100 * time.Millisecond, expOK: true},
+				{at: 200 * time.Millisecond, maxWait: 2 * time.Second, expWait: 800 * time.Millisecond, expOK: true, cancel: true},
+				{at: 200 * time.Millisecond, maxWait: 2 * time.Second, expWait: 800 * time.Millisecond, expOK: true},
+			},
+		},
+		{
+			name: "Sliding window counter should weight the previous window executions to allow the executions.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewSlidingWindowCounter(ratelimit.SlidingWindowCounterConfig{Limit: 4, Window: 1 * time.Second})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 0, expOK: true},
+				{at: 0, expOK: true},
+				{at: 0, expOK: true},
+				{at: 0, expOK: false},
+				{at: 0, maxWait: 2 * time.Second, expWait: 1250 * time.Millisecond, expOK: true},
+				{at: 1500 * time.Millisecond, expOK: true},
+				{at: 1500 * time.Millisecond, expOK: false},
+				{at: 1500 * time.Millisecond, maxWait: 1 * time.Second, expWait: 250 * time.Millisecond, expOK: true},
+				{at: 2600 * time.Millisecond, expOK: true},
+			},
+		},
+		{
+			name: "Sliding window counter should remove the cancelled reservations.",
+			algorithm: func() ratelimit.Algorithm {
+				return ratelimit.NewSlidingWindowCounter(ratelimit.SlidingWindowCounterConfig{Limit: 1, Window: 1 * time.Second})
+			},
+			reservations: []reservation{
+				{at: 0, expOK: true},
+				{at: 0, maxWait: 5 * time.Second, expWait: 2 * time.Second, expOK: true, cancel: true},
+				{at: 0, maxWait: 5 * time.Second, expWait: 2 * time.Second, expOK: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			alg := test.algorithm()
+			for i, r := range test.reservations {
+				now := start.Add(r.at)
+				wait, ok := alg.Reserve(now, r.maxWait)
+				assert.Equal(r.expOK, ok, "reservation %d", i)
+				assert.Equal(r.expWait, wait, "reservation %d", i)
+
+				if r.cancel {
+					alg.Cancel(now.Add(wait))
+				}
+			}
+		})
+	}
+}
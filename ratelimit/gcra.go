@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// GCRAConfig is the configuration of the GCRA algorithm.
+type GCRAConfig struct {
+	// Rate is the number of executions per second that will be allowed.
+	Rate float64
+	// Burst is the max number of executions that can be allowed at once.
+	Burst int
+}
+
+func (c *GCRAConfig) defaults() {
+	if c.Rate <= 0 {
+		c.Rate = 100
+	}
+
+	if c.Burst <= 0 {
+		c.Burst = 1
+	}
+}
+
+type gcra struct {
+	// emissionInterval is the time between executions at the configured rate.
+	emissionInterval time.Duration
+	// tolerance is how early an execution can be allowed from its theoretical arrival time.
+	tolerance time.Duration
+	// tat is the theoretical arrival time of the next execution.
+	tat time.Time
+	mu  sync.Mutex
+}
+
+// NewGCRA returns a new generic cell rate Algorithm. Instead of refilling tokens
+// it tracks the theoretical arrival time (TAT) of the next execution, an execution
+// is allowed if it doesn't arrive earlier than the TAT minus the burst tolerance.
+// More information about this algorithm in: https://en.wikipedia.org/wiki/Generic_cell_rate_algorithm
+func NewGCRA(cfg GCRAConfig) Algorithm {
+	cfg.defaults()
+
+	emissionInterval := time.Duration(float64(time.Second) / cfg.Rate)
+	return &gcra{
+		emissionInterval: emissionInterval,
+		tolerance:        emissionInterval * time.Duration(cfg.Burst-1),
+	}
+}
+
+// Reserve satisfies Algorithm interface.
+func (g *gcra) Reserve(now time.Time, maxWait time.Duration) (wait time.Duration, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tat := g.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	allowAt := tat.Add(-g.tolerance)
+	if allowAt.After(now) {
+		wait = allowAt.Sub(now)
+	}
+
+	if wait > maxWait {
+		return 0, false
+	}
+
+	g.tat = tat.Add(g.emissionInterval)
+	return wait, true
+}
+
+// Cancel satisfies Algorithm interface.
+func (g *gcra) Cancel(_ time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tat = g.tat.Add(-g.emissionInterval)
+}
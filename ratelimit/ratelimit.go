@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/metrics"
+)
+
+// Config is the configuration of the rate limit runner.
+type Config struct {
+	// Rate is the number of executions per second that will be allowed by the
+	// default token bucket algorithm.
+	Rate float64
+	// Burst is the max number of executions that can be allowed at once by the
+	// default token bucket algorithm, in other words, the size of the bucket.
+	Burst int
+	// Algorithm is the rate limit algorithm implementation that will decide
+	// if the executions are allowed or not. If set, Rate and Burst will be ignored.
+	// By default a token bucket with Rate and Burst.
+	Algorithm Algorithm
+	// MaxWaitTime is the max time an execution will wait to be allowed before
+	// being rejected, if 0 the execution will be rejected directly when the rate
+	// has been exceeded. The wait time is also limited by the context deadline.
+	MaxWaitTime time.Duration
+}
+
+func (c *Config) defaults() {
+	if c.Algorithm == nil {
+		c.Algorithm = NewTokenBucket(TokenBucketConfig{
+			Rate:  c.Rate,
+			Burst: c.Burst,
+		})
+	}
+
+	if c.MaxWaitTime < 0 {
+		c.MaxWaitTime = 0
+	}
+}
+
+type ratelimit struct {
+	cfg    Config
+	runner goresilience.Runner
+}
+
+// New returns a new rate limit runner.
+//
+// The rate limiter will ask the rate limit algorithm (by default a token
+// bucket) if the execution is allowed, if the rate has been exceeded
+// the execution will wait until is allowed (up to a max wait time or
+// the context deadline) or will be rejected with an `errors.ErrRateLimited`
+// error.
+func New(cfg Config) goresilience.Runner {
+	return NewMiddleware(cfg)(nil)
+}
+
+// NewMiddleware returns a middleware that uses the Runner returned
+// by ratelimit.New.
+func NewMiddleware(cfg Config) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &ratelimit{
+			cfg:    cfg,
+			runner: goresilience.SanitizeRunner(next),
+		}
+	}
+}
+
+func (r *ratelimit) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+	start := time.Now()
+
+	// We can't wait more than the context deadline.
+	maxWait := r.cfg.MaxWaitTime
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := deadline.Sub(start); remaining < maxWait {
+			maxWait = remaining
+		}
+	}
+
+	wait, ok := r.cfg.Algorithm.Reserve(start, maxWait)
+	if !ok {
+		metricsRecorder.IncRateLimitResult(false)
+		return errors.ErrRateLimited
+	}
+
+	// Wait for our token.
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			r.cfg.Algorithm.Cancel(start.Add(wait))
+			metricsRecorder.IncRateLimitResult(false)
+			return errors.ErrContextCanceled
+		}
+	}
+
+	metricsRecorder.ObserveRateLimitWaitTime(start)
+	metricsRecorder.IncRateLimitResult(true)
+
+	return r.runner.Run(ctx, f)
+}
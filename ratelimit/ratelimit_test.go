@@ -0,0 +1,130 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience"
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/ratelimit"
+)
+
+var fOK = func(_ context.Context) error { return nil }
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          ratelimit.Config
+		ctxTimeout   time.Duration
+		timesToCall  int
+		expAllowed   int
+		expLimited   int
+		expLimitErr  error
+		expMinWaited time.Duration
+	}{
+		{
+			name: "A rate limiter without wait should reject directly the executions that exceed the burst.",
+			cfg: ratelimit.Config{
+				Rate:  1,
+				Burst: 5,
+			},
+			timesToCall: 10,
+			expAllowed:  5,
+			expLimited:  5,
+			expLimitErr: grerrors.ErrRateLimited,
+		},
+		{
+			name: "A rate limiter with wait should wait for the tokens to execute.",
+			cfg: ratelimit.Config{
+				Rate:        100,
+				Burst:       1,
+				MaxWaitTime: 100 * time.Millisecond,
+			},
+			timesToCall:  4,
+			expAllowed:   4,
+			expLimited:   0,
+			expMinWaited: 30 * time.Millisecond,
+		},
+		{
+			name: "A rate limiter with wait should reject the executions that would need to wait more than the max wait time.",
+			cfg: ratelimit.Config{
+				Rate:        100,
+				Burst:       1,
+				MaxWaitTime: 25 * time.Millisecond,
+			},
+			timesToCall: 5,
+			expAllowed:  3,
+			expLimited:  2,
+			expLimitErr: grerrors.ErrRateLimited,
+		},
+		{
+			name: "A rate limiter with wait should reject the executions that would exceed the context deadline.",
+			cfg: ratelimit.Config{
+				Rate:        100,
+				Burst:       1,
+				MaxWaitTime: 1 * time.Second,
+			},
+			ctxTimeout:  25 * time.Millisecond,
+			timesToCall: 5,
+			expAllowed:  3,
+			expLimited:  2,
+			expLimitErr: grerrors.ErrRateLimited,
+		},
+		{
+			name: "A rate limiter with a custom algorithm should use the algorithm instead of the rate and burst.",
+			cfg: ratelimit.Config{
+				Rate:  100,
+				Burst: 100,
+				Algorithm: ratelimit.NewSlidingLog(ratelimit.SlidingLogConfig{
+					Limit:  2,
+					Window: 1 * time.Second,
+				}),
+			},
+			timesToCall: 5,
+			expAllowed:  2,
+			expLimited:  3,
+			expLimitErr: grerrors.ErrRateLimited,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := goresilience.RunnerChain(ratelimit.NewMiddleware(test.cfg))
+			ctx := context.Background()
+			if test.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.ctxTimeout)
+				defer cancel()
+			}
+
+			// Call concurrently so the executions need to wait for the tokens.
+			start := time.Now()
+			results := make(chan error)
+			for i := 0; i < test.timesToCall; i++ {
+				go func() {
+					results <- runner.Run(ctx, fOK)
+				}()
+			}
+
+			gotAllowed, gotLimited := 0, 0
+			for i := 0; i < test.timesToCall; i++ {
+				err := <-results
+				if err != nil {
+					assert.Equal(test.expLimitErr, err)
+					gotLimited++
+					continue
+				}
+				gotAllowed++
+			}
+
+			assert.Equal(test.expAllowed, gotAllowed)
+			assert.Equal(test.expLimited, gotLimited)
+			assert.True(time.Since(start) >= test.expMinWaited)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// SlidingLogConfig is the configuration of the sliding log algorithm.
+type SlidingLogConfig struct {
+	// Limit is the max number of executions allowed in the window.
+	Limit int
+	// Window is the duration of the sliding window.
+	Window time.Duration
+}
+
+func (c *SlidingLogConfig) defaults() {
+	if c.Limit <= 0 {
+		c.Limit = 100
+	}
+
+	if c.Window <= 0 {
+		c.Window = 1 * time.Second
+	}
+}
+
+type slidingLog struct {
+	cfg SlidingLogConfig
+	// log has the time of the allowed executions (in order), it can have
+	// times in the future for the executions that are waiting.
+	log []time.Time
+	mu  sync.Mutex
+}
+
+// NewSlidingLog returns a new sliding log Algorithm. It stores the time of every
+// allowed execution and an execution is only allowed if the number of executions
+// in the last window duration is less than the limit. It's precise but the memory
+// usage is proportional to the limit.
+func NewSlidingLog(cfg SlidingLogConfig) Algorithm {
+	cfg.defaults()
+
+	return &slidingLog{
+		cfg: cfg,
+		log: make([]time.Time, 0, cfg.Limit),
+	}
+}
+
+// Reserve satisfies Algorithm interface.
+func (s *slidingLog) Reserve(now time.Time, maxWait time.Duration) (wait time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Remove the executions out of the window.
+	windowStart := now.Add(-s.cfg.Window)
+	i := 0
+	for i < len(s.log) && !s.log[i].After(windowStart) {
+		i++
+	}
+	s.log = s.log[i:]
+
+	// If we are on the limit, we will be allowed when the required oldest
+	// execution leaves the window.
+	allowAt := now
+	if len(s.log) >= s.cfg.Limit {
+		allowAt = s.log[len(s.log)-s.cfg.Limit].Add(s.cfg.Window)
+		wait = allowAt.Sub(now)
+	}
+
+	if wait > maxWait {
+		return 0, false
+	}
+
+	s.log = append(s.log, allowAt)
+	return wait, true
+}
+
+// Cancel satisfies Algorithm interface.
+func (s *slidingLog) Cancel(allowedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.log) - 1; i >= 0; i-- {
+		if s.log[i].Equal(allowedAt) {
+			s.log = append(s.log[:i], s.log[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// SlidingWindowCounterConfig is the configuration of the sliding window counter algorithm.
+type SlidingWindowCounterConfig struct {
+	// Limit is the max number of executions allowed in the window.
+	Limit int
+	// Window is the duration of the sliding window.
+	Window time.Duration
+}
+
+func (c *SlidingWindowCounterConfig) defaults() {
+	if c.Limit <= 0 {
+		c.Limit = 100
+	}
+
+	if c.Window <= 0 {
+		c.Window = 1 * time.Second
+	}
+}
+
+type slidingWindowCounter struct {
+	cfg SlidingWindowCounterConfig
+	// counts are the number of allowed executions by window index, it
+	// can have windows in the future for the executions that are waiting.
+	counts map[int64]int
+	mu     sync.Mutex
+}
+
+// NewSlidingWindowCounter returns a new sliding window counter Algorithm. It counts
+// the executions on fixed windows and approximates the executions of the sliding
+// window using the weighted count of the previous window based on how much of the
+// previous window overlaps the sliding window. It uses constant memory.
+func NewSlidingWindowCounter(cfg SlidingWindowCounterConfig) Algorithm {
+	cfg.defaults()
+
+	return &slidingWindowCounter{
+		cfg:    cfg,
+		counts: map[int64]int{},
+	}
+}
+
+// Reserve satisfies Algorithm interface.
+func (s *slidingWindowCounter) Reserve(now time.Time, maxWait time.Duration) (wait time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Remove the windows that don't affect anymore.
+	current := s.windowIndex(now)
+	for idx := range s.counts {
+		if idx < current-1 {
+			delete(s.counts, idx)
+		}
+	}
+
+	// Search the first moment (starting on the current window) where
+	// the execution could be allowed.
+	limit := now.Add(maxWait)
+	for idx := current; ; idx++ {
+		windowStart := time.Unix(0, idx*int64(s.cfg.Window))
+		if windowStart.After(limit) {
+			return 0, false
+		}
+
+		curr := s.counts[idx]
+		if curr+1 > s.cfg.Limit {
+			continue
+		}
+
+		// Calculate how much of the window needs to pass so the weight of the previous
+		// window is low enough to allow the execution:
+		// prev * (1 - elapsed/window) + curr + 1 <= limit.
+		allowAt := windowStart
+		if prev := s.counts[idx-1]; prev > 0 {
+			ratio := 1 - float64(s.cfg.Limit-curr-1)/float64(prev)
+			if ratio > 0 {
+				allowAt = allowAt.Add(time.Duration(ratio * float64(s.cfg.Window)))
+			}
+		}
+		if allowAt.Before(now) {
+			allowAt = now
+		}
+
+		// If we can't be allowed on this window, try with the next one.
+		if s.windowIndex(allowAt) != idx {
+			continue
+		}
+
+		wait = allowAt.Sub(now)
+		if wait > maxWait {
+			return 0, false
+		}
+
+		s.counts[idx]++
+		return wait, true
+	}
+}
+
+// Cancel satisfies Algorithm interface.
+func (s *slidingWindowCounter) Cancel(allowedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.windowIndex(allowedAt)
+	if s.counts[idx] > 0 {
+		s.counts[idx]--
+	}
+}
+
+func (s *slidingWindowCounter) windowIndex(t time.Time) int64 {
+	return t.UnixNano() / int64(s.cfg.Window)
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucketConfig is the configuration of the token bucket algorithm.
+type TokenBucketConfig struct {
+	// Rate is the number of executions per second that will be allowed.
+	Rate float64
+	// Burst is the max number of executions that can be allowed at once, in
+	// other words, the size of the bucket.
+	Burst int
+}
+
+func (c *TokenBucketConfig) defaults() {
+	if c.Rate <= 0 {
+		c.Rate = 100
+	}
+
+	if c.Burst <= 0 {
+		c.Burst = int(c.Rate)
+		if c.Burst < 1 {
+			c.Burst = 1
+		}
+	}
+}
+
+// tokenBucket is a token bucket that is refilled at a constant rate.
+// The tokens can be reserved in advance, this makes the token quantity
+// negative and the next reservations will need to wait more.
+type tokenBucket struct {
+	rate   float64 // tokens per second.
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// NewTokenBucket returns a new token bucket Algorithm. The bucket is refilled at a
+// constant rate and every execution takes a token from the bucket, the bucket
+// starts full so it allows bursts of executions up to the size of the bucket.
+// More information about this algorithm in: https://en.wikipedia.org/wiki/Token_bucket
+func NewTokenBucket(cfg TokenBucketConfig) Algorithm {
+	cfg.defaults()
+
+	return &tokenBucket{
+		rate:   cfg.Rate,
+		burst:  float64(cfg.Burst),
+		tokens: float64(cfg.Burst),
+	}
+}
+
+// Reserve satisfies Algorithm interface.
+func (t *tokenBucket) Reserve(now time.Time, maxWait time.Duration) (wait time.Duration, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.refill(now)
+
+	// Check how much we would need to wait for the token.
+	tokens := t.tokens - 1
+	if tokens < 0 {
+		wait = time.Duration(-tokens / t.rate * float64(time.Second))
+	}
+
+	if wait > maxWait {
+		return 0, false
+	}
+
+	t.tokens = tokens
+	return wait, true
+}
+
+// Cancel satisfies Algorithm interface.
+func (t *tokenBucket) Cancel(_ time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tokens++
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+}
+
+// refill will add to the bucket the tokens generated since the last refill.
+func (t *tokenBucket) refill(now time.Time) {
+	if t.last.IsZero() {
+		t.last = now
+	}
+
+	elapsed := now.Sub(t.last)
+	if elapsed <= 0 {
+		return
+	}
+
+	t.last = now
+	t.tokens += elapsed.Seconds() * t.rate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+}
@@ -0,0 +1,48 @@
+package recovery
+
+import (
+	"context"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/metrics"
+)
+
+// New returns a new recovery runner. The recovery runner will recover the
+// panics of the execution and convert them into a `errors.PanicError` error
+// (that wraps `errors.ErrPanic`) with the panic value and the stack trace.
+//
+// A panic can only be recovered on the same goroutine where it happened, so
+// this runner should be the last of the chain, after the runners that
+// execute the Func on other goroutines (e.g timeout).
+func New() goresilience.Runner {
+	return NewMiddleware()(nil)
+}
+
+// NewMiddleware returns a middleware that uses the Runner returned
+// by recovery.New.
+func NewMiddleware() goresilience.Middleware {
+	return func(next goresilience.Runner) goresilience.Runner {
+		next = goresilience.SanitizeRunner(next)
+		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
+			return Call(ctx, func() error {
+				return next.Run(ctx, f)
+			})
+		})
+	}
+}
+
+// Call will call the function and in case of panicking it will recover and return
+// a `errors.PanicError` error, the recovered panic will be measured with the
+// metrics recorder of the context.
+func Call(ctx context.Context, f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+			metricsRecorder.IncPanicRecovered()
+			err = errors.NewPanicError(r)
+		}
+	}()
+
+	return f()
+}
@@ -0,0 +1,62 @@
+package recovery_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience"
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/recovery"
+)
+
+var errWanted = errors.New("wanted error")
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		name          string
+		f             goresilience.Func
+		expErr        error
+		expPanicValue interface{}
+	}{
+		{
+			name:   "An execution without panic should return the execution result.",
+			f:      func(_ context.Context) error { return nil },
+			expErr: nil,
+		},
+		{
+			name:   "An execution without panic should return the execution error.",
+			f:      func(_ context.Context) error { return errWanted },
+			expErr: errWanted,
+		},
+		{
+			name:          "An execution that panics should return a panic error.",
+			f:             func(_ context.Context) error { panic("wanted panic") },
+			expErr:        grerrors.ErrPanic,
+			expPanicValue: "wanted panic",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			runner := recovery.New()
+			err := runner.Run(context.TODO(), test.f)
+
+			if test.expPanicValue == nil {
+				assert.Equal(test.expErr, err)
+				return
+			}
+
+			var perr *grerrors.PanicError
+			if assert.True(errors.As(err, &perr)) {
+				assert.True(errors.Is(err, test.expErr))
+				assert.Equal(test.expPanicValue, perr.Value)
+				assert.NotEmpty(perr.Stack)
+			}
+		})
+	}
+}
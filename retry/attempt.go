@@ -0,0 +1,36 @@
+package retry
+
+import (
+	"context"
+)
+
+var ctxAttemptKey contextKey = "attempt"
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "retry-ctx-key" + string(c)
+}
+
+// Attempt has the information of the current attempt of an execution, the retry
+// runner will set it on the context of every execution (the hedge runner has its
+// own, see `hedge.AttemptFromContext`).
+type Attempt struct {
+	// Number is the number of the attempt, the first execution is the attempt 1.
+	Number int
+	// Total is the total number of attempts allowed.
+	Total int
+	// PrevErr is the error of the previous attempt, nil on the first attempt.
+	PrevErr error
+}
+
+// AttemptFromContext will get the attempt information of the execution from the context.
+func AttemptFromContext(ctx context.Context) (attempt Attempt, ok bool) {
+	attempt, ok = ctx.Value(ctxAttemptKey).(Attempt)
+	return attempt, ok
+}
+
+// SetAttemptOnContext will set the attempt information of the execution on the context.
+func SetAttemptOnContext(ctx context.Context, attempt Attempt) context.Context {
+	return context.WithValue(ctx, ctxAttemptKey, attempt)
+}
@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff knows how much time needs to wait before the next retry, check
+// https://aws.amazon.com/es/blogs/architecture/exponential-backoff-and-jitter/
+// for more information about the different backoff strategies.
+type Backoff interface {
+	// Wait returns the duration that needs to be waited before the retry number
+	// (starts at 1), it also receives the duration waited before the previous retry
+	// (0 on the first retry).
+	Wait(retry int, prevWait time.Duration) time.Duration
+}
+
+// BackoffFunc is a helper that will satisfy Backoff interface by using a function.
+type BackoffFunc func(retry int, prevWait time.Duration) time.Duration
+
+// Wait satisfies Backoff interface.
+func (b BackoffFunc) Wait(retry int, prevWait time.Duration) time.Duration {
+	return b(retry, prevWait)
+}
+
+// NewConstantBackoff returns a backoff that will always wait the same duration.
+func NewConstantBackoff(wait time.Duration) Backoff {
+	return BackoffFunc(func(_ int, _ time.Duration) time.Duration {
+		return wait
+	})
+}
+
+// NewLinearBackoff returns a backoff that will increase the wait by the
+// base duration on each retry (base, 2*base, 3*base...).
+func NewLinearBackoff(base time.Duration) Backoff {
+	return BackoffFunc(func(retry int, _ time.Duration) time.Duration {
+		return safeDuration(float64(base) * float64(retry))
+	})
+}
+
+// NewExponentialBackoff returns a backoff that will double the wait on
+// each retry (base, 2*base, 4*base...).
+func NewExponentialBackoff(base time.Duration) Backoff {
+	return BackoffFunc(func(retry int, _ time.Duration) time.Duration {
+		return exponential(base, retry)
+	})
+}
+
+// NewFullJitterBackoff returns an exponential backoff where the wait is a random
+// duration between 0 and the exponential wait.
+func NewFullJitterBackoff(base time.Duration) Backoff {
+	return BackoffFunc(func(retry int, _ time.Duration) time.Duration {
+		wait := exponential(base, retry)
+		return time.Duration(float64(wait) * rand.Float64())
+	})
+}
+
+// NewEqualJitterBackoff returns an exponential backoff where the wait is the half
+// of the exponential wait plus a random duration between 0 and the other half.
+func NewEqualJitterBackoff(base time.Duration) Backoff {
+	return BackoffFunc(func(retry int, _ time.Duration) time.Duration {
+		half := exponential(base, retry) / 2
+		return half + time.Duration(float64(half)*rand.Float64())
+	})
+}
+
+// NewDecorrelatedJitterBackoff returns a backoff where the wait is a random duration
+// between the base and three times the previous wait. It should be used with a
+// max wait, otherwise the wait will grow without limits.
+func NewDecorrelatedJitterBackoff(base time.Duration) Backoff {
+	return BackoffFunc(func(_ int, prevWait time.Duration) time.Duration {
+		if prevWait < base {
+			prevWait = base
+		}
+		upper := safeDuration(float64(prevWait) * 3)
+		return base + time.Duration(float64(upper-base)*rand.Float64())
+	})
+}
+
+// NewFibonacciBackoff returns a backoff that will increase the wait following the
+// Fibonacci sequence (base, base, 2*base, 3*base, 5*base...).
+func NewFibonacciBackoff(base time.Duration) Backoff {
+	return BackoffFunc(func(retry int, _ time.Duration) time.Duration {
+		a, b := 0.0, 1.0
+		for i := 1; i < retry && !math.IsInf(b, 1); i++ {
+			a, b = b, a+b
+		}
+		return safeDuration(float64(base) * b)
+	})
+}
+
+// exponential returns the exponential wait for a retry.
+func exponential(base time.Duration, retry int) time.Duration {
+	return safeDuration(float64(base) * math.Exp2(float64(retry-1)))
+}
+
+// safeDuration converts a float to a duration without overflowing.
+func safeDuration(d float64) time.Duration {
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
@@ -0,0 +1,118 @@
+package retry_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience/retry"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		backoff  retry.Backoff
+		expWaits []time.Duration
+	}{
+		{
+			name:     "Constant backoff should wait always the same duration.",
+			backoff:  retry.NewConstantBackoff(10 * time.Millisecond),
+			expWaits: []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond},
+		},
+		{
+			name:     "Linear backoff should increase the wait by the base duration.",
+			backoff:  retry.NewLinearBackoff(10 * time.Millisecond),
+			expWaits: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 40 * time.Millisecond},
+		},
+		{
+			name:     "Exponential backoff should double the wait.",
+			backoff:  retry.NewExponentialBackoff(10 * time.Millisecond),
+			expWaits: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond},
+		},
+		{
+			name:     "Fibonacci backoff should increase the wait following the Fibonacci sequence.",
+			backoff:  retry.NewFibonacciBackoff(10 * time.Millisecond),
+			expWaits: []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 50 * time.Millisecond},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var gotWaits []time.Duration
+			var prevWait time.Duration
+			for i := range test.expWaits {
+				prevWait = test.backoff.Wait(i+1, prevWait)
+				gotWaits = append(gotWaits, prevWait)
+			}
+
+			assert.Equal(test.expWaits, gotWaits)
+		})
+	}
+}
+
+func TestJitterBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff retry.Backoff
+		// expMin and expMax return the allowed range of the wait for a retry.
+		expMin func(retry int, prevWait time.Duration) time.Duration
+		expMax func(retry int, prevWait time.Duration) time.Duration
+	}{
+		{
+			name:    "Full jitter backoff should wait between 0 and the exponential wait.",
+			backoff: retry.NewFullJitterBackoff(10 * time.Millisecond),
+			expMin:  func(_ int, _ time.Duration) time.Duration { return 0 },
+			expMax: func(retry int, _ time.Duration) time.Duration {
+				return 10 * time.Millisecond << uint(retry-1)
+			},
+		},
+		{
+			name:    "Equal jitter backoff should wait between the half and the exponential wait.",
+			backoff: retry.NewEqualJitterBackoff(10 * time.Millisecond),
+			expMin: func(retry int, _ time.Duration) time.Duration {
+				return 5 * time.Millisecond << uint(retry-1)
+			},
+			expMax: func(retry int, _ time.Duration) time.Duration {
+				return 10 * time.Millisecond << uint(retry-1)
+			},
+		},
+		{
+			name:    "Decorrelated jitter backoff should wait between the base and three times the previous wait.",
+			backoff: retry.NewDecorrelatedJitterBackoff(10 * time.Millisecond),
+			expMin:  func(_ int, _ time.Duration) time.Duration { return 10 * time.Millisecond },
+			expMax: func(_ int, prevWait time.Duration) time.Duration {
+				if prevWait < 10*time.Millisecond {
+					prevWait = 10 * time.Millisecond
+				}
+				return 3 * prevWait
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			for i := 0; i < 100; i++ {
+				var prevWait time.Duration
+				for retry := 1; retry <= 5; retry++ {
+					wait := test.backoff.Wait(retry, prevWait)
+					assert.True(wait >= test.expMin(retry, prevWait), "wait %s is less than the minimum", wait)
+					assert.True(wait <= test.expMax(retry, prevWait), "wait %s is greater than the maximum", wait)
+					prevWait = wait
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffOverflow(t *testing.T) {
+	assert := assert.New(t)
+
+	wait := retry.NewExponentialBackoff(time.Second).Wait(1000, 0)
+	assert.Equal(time.Duration(math.MaxInt64), wait)
+}
@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"sync"
+)
+
+// BudgetConfig is the configuration of the retry Budget.
+type BudgetConfig struct {
+	// Ratio is the fraction of a token that every successful execution deposits
+	// on the budget. As every retry withdraws a full token, this is the ratio of
+	// retries allowed over the successful executions, e.g 0.1 will allow retries
+	// up to the 10% of the successful executions.
+	Ratio float64
+	// MaxTokens is the maximum number of tokens the budget can have, this is the
+	// number of retries that can be made in a burst. The budget starts full.
+	MaxTokens float64
+}
+
+func (c *BudgetConfig) defaults() {
+	if c.Ratio <= 0 {
+		c.Ratio = 0.1
+	}
+
+	if c.MaxTokens <= 0 {
+		c.MaxTokens = 10
+	}
+}
+
+// Budget limits the number of retries based on the number of successful executions,
+// this way the retries can't amplify the load on a downstream service that is having
+// problems (e.g 3 retries per execution would multiply the load by 4).
+//
+// The same budget can (and should) be shared between multiple retry runners so
+// the budget is applied to all the executions of a service.
+type Budget struct {
+	cfg    BudgetConfig
+	tokens float64
+	mu     sync.Mutex
+}
+
+// NewBudget returns a new retry budget.
+func NewBudget(cfg BudgetConfig) *Budget {
+	cfg.defaults()
+
+	return &Budget{
+		cfg:    cfg,
+		tokens: cfg.MaxTokens,
+	}
+}
+
+// Deposit deposits the ratio of a token on the budget, it should be called
+// when an execution succeeds. It returns the tokens available on the budget.
+func (b *Budget) Deposit() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.cfg.Ratio
+	if b.tokens > b.cfg.MaxTokens {
+		b.tokens = b.cfg.MaxTokens
+	}
+
+	return b.tokens
+}
+
+// Withdraw withdraws a token from the budget, it should be called before
+// a retry. It returns false if there aren't tokens available for the retry
+// and the tokens available on the budget.
+func (b *Budget) Withdraw() (bool, float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false, b.tokens
+	}
+
+	b.tokens--
+	return true, b.tokens
+}
+
+// Tokens returns the tokens available on the budget.
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens
+}
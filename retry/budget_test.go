@@ -0,0 +1,76 @@
+package retry_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience/retry"
+)
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       retry.BudgetConfig
+		deposits  int
+		withdraws int
+		expAllows int
+		expTokens float64
+	}{
+		{
+			name:      "A new budget should allow retries until the max tokens.",
+			cfg:       retry.BudgetConfig{MaxTokens: 3},
+			withdraws: 5,
+			expAllows: 3,
+			expTokens: 0,
+		},
+		{
+			name:      "Deposits should not exceed the max tokens.",
+			cfg:       retry.BudgetConfig{Ratio: 0.5, MaxTokens: 3},
+			deposits:  10,
+			withdraws: 5,
+			expAllows: 3,
+			expTokens: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b := retry.NewBudget(test.cfg)
+			for i := 0; i < test.deposits; i++ {
+				b.Deposit()
+			}
+
+			allows := 0
+			for i := 0; i < test.withdraws; i++ {
+				if ok, _ := b.Withdraw(); ok {
+					allows++
+				}
+			}
+
+			assert.Equal(test.expAllows, allows)
+			assert.Equal(test.expTokens, b.Tokens())
+		})
+	}
+}
+
+func TestBudgetRatio(t *testing.T) {
+	assert := assert.New(t)
+
+	b := retry.NewBudget(retry.BudgetConfig{Ratio: 0.25, MaxTokens: 1})
+	ok, _ := b.Withdraw()
+	assert.True(ok)
+
+	// We need 4 successful executions to get a token.
+	for i := 0; i < 3; i++ {
+		b.Deposit()
+		ok, _ := b.Withdraw()
+		assert.False(ok)
+	}
+	b.Deposit()
+	ok, tokens := b.Withdraw()
+	assert.True(ok)
+	assert.Equal(0.0, tokens)
+}
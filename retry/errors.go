@@ -0,0 +1,95 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AbortedError is the error returned when the retries have been aborted before
+// reaching the configured retry times (the context has been cancelled, the max
+// elapsed time has been reached...).
+//
+// It can be checked with `errors.Is` against the reason of the abort
+// (e.g `errors.ErrRetryMaxElapsedTime`) and also against the error of the last
+// attempt (or the errors of all the attempts, see ReturnAllErrors).
+type AbortedError struct {
+	// Reason is the reason of the abort.
+	Reason error
+	// Err is the error of the last attempt, or the ExhaustedError with the errors
+	// of all the attempts made if the runner returns all the errors.
+	Err error
+}
+
+func (e *AbortedError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Reason, e.Err)
+}
+
+// Unwrap returns the error of the attempts.
+func (e *AbortedError) Unwrap() error {
+	return e.Err
+}
+
+// Is satisfies the interface used by `errors.Is` to check against the reason of the abort.
+func (e *AbortedError) Is(target error) bool {
+	return errors.Is(e.Reason, target)
+}
+
+// AttemptError is the error of a failed attempt with its timing.
+type AttemptError struct {
+	// Err is the error returned by the attempt.
+	Err error
+	// Start is the time when the attempt started.
+	Start time.Time
+	// Duration is the duration of the attempt.
+	Duration time.Duration
+}
+
+// ExhaustedError is the error returned when all the attempts failed and the
+// retry runner has been configured to return all the errors of the attempts.
+// It's also returned when the retries end early because of an error that
+// should not be retried, with the attempts made.
+//
+// It can be checked with `errors.Is` and `errors.As` against the errors of any
+// of the attempts.
+type ExhaustedError struct {
+	// Attempts are the failed attempts in order of execution.
+	Attempts []AttemptError
+}
+
+func (e *ExhaustedError) Error() string {
+	msgs := make([]string, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		msgs = append(msgs, a.Err.Error())
+	}
+	return fmt.Sprintf("all %d attempts failed: %s", len(e.Attempts), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *ExhaustedError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// Is satisfies the interface used by `errors.Is` to check against the errors of all the attempts.
+func (e *ExhaustedError) Is(target error) bool {
+	for _, a := range e.Attempts {
+		if errors.Is(a.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As satisfies the interface used by `errors.As` to check against the errors of all the attempts.
+func (e *ExhaustedError) As(target interface{}) bool {
+	for _, a := range e.Attempts {
+		if errors.As(a.Err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package retry
+
+import (
+	"context"
+	"errors"
+
+	grerrors "github.com/slok/goresilience/errors"
+)
+
+// RetryablePolicy is the function that will have the responsibility of deciding
+// if an execution error should be retried or not. For example business errors
+// like validation errors will never succeed no matter how many times are retried.
+type RetryablePolicy func(ctx context.Context, err error) bool
+
+// RetryAllPolicy will retry every error.
+var RetryAllPolicy RetryablePolicy = func(_ context.Context, err error) bool {
+	return err != nil
+}
+
+// NoRetryOnRejectedPolicy will retry every error except the ones that mean the
+// execution has been rejected by a runner on purpose (the circuit is open, the
+// rate has been exceeded...), retrying these would only amplify the load.
+var NoRetryOnRejectedPolicy RetryablePolicy = func(_ context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, grerrors.ErrCircuitOpen),
+		errors.Is(err, grerrors.ErrRateLimited),
+		errors.Is(err, grerrors.ErrRejectedExecution):
+		return false
+	}
+
+	return true
+}
+
+// RetryOnTimeoutPolicy will only retry the errors that are timeouts.
+var RetryOnTimeoutPolicy RetryablePolicy = func(_ context.Context, err error) bool {
+	return errors.Is(err, grerrors.ErrTimeout)
+}
+
+// temporary is the interface of the errors that know if they are temporary,
+// like the ones from the net package.
+type temporary interface {
+	Temporary() bool
+}
+
+// RetryOnTemporaryPolicy will only retry the errors that implement a `Temporary() bool`
+// method and are temporary, like the ones from the net package.
+var RetryOnTemporaryPolicy RetryablePolicy = func(_ context.Context, err error) bool {
+	var terr temporary
+	return errors.As(err, &terr) && terr.Temporary()
+}
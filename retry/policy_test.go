@@ -0,0 +1,103 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/retry"
+)
+
+type temporaryErr bool
+
+func (t temporaryErr) Error() string   { return "temporary error" }
+func (t temporaryErr) Temporary() bool { return bool(t) }
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   retry.RetryablePolicy
+		err      error
+		expRetry bool
+	}{
+		{
+			name:     "RetryAllPolicy should retry any error.",
+			policy:   retry.RetryAllPolicy,
+			err:      errors.New("external error"),
+			expRetry: true,
+		},
+		{
+			name:     "RetryAllPolicy should retry a rejected error.",
+			policy:   retry.RetryAllPolicy,
+			err:      grerrors.ErrCircuitOpen,
+			expRetry: true,
+		},
+		{
+			name:     "NoRetryOnRejectedPolicy should retry an external error.",
+			policy:   retry.NoRetryOnRejectedPolicy,
+			err:      errors.New("external error"),
+			expRetry: true,
+		},
+		{
+			name:     "NoRetryOnRejectedPolicy should not retry a circuit open error.",
+			policy:   retry.NoRetryOnRejectedPolicy,
+			err:      grerrors.ErrCircuitOpen,
+			expRetry: false,
+		},
+		{
+			name:     "NoRetryOnRejectedPolicy should not retry a rate limited error.",
+			policy:   retry.NoRetryOnRejectedPolicy,
+			err:      grerrors.ErrRateLimited,
+			expRetry: false,
+		},
+		{
+			name:     "NoRetryOnRejectedPolicy should not retry a wrapped rejected execution error.",
+			policy:   retry.NoRetryOnRejectedPolicy,
+			err:      fmt.Errorf("wrapped: %w", grerrors.ErrRejectedExecution),
+			expRetry: false,
+		},
+		{
+			name:     "RetryOnTimeoutPolicy should retry a timeout error.",
+			policy:   retry.RetryOnTimeoutPolicy,
+			err:      grerrors.ErrTimeout,
+			expRetry: true,
+		},
+		{
+			name:     "RetryOnTimeoutPolicy should not retry an external error.",
+			policy:   retry.RetryOnTimeoutPolicy,
+			err:      errors.New("external error"),
+			expRetry: false,
+		},
+		{
+			name:     "RetryOnTemporaryPolicy should retry a temporary error.",
+			policy:   retry.RetryOnTemporaryPolicy,
+			err:      fmt.Errorf("wrapped: %w", temporaryErr(true)),
+			expRetry: true,
+		},
+		{
+			name:     "RetryOnTemporaryPolicy should not retry a not temporary error.",
+			policy:   retry.RetryOnTemporaryPolicy,
+			err:      temporaryErr(false),
+			expRetry: false,
+		},
+		{
+			name:     "RetryOnTemporaryPolicy should not retry an error without temporary information.",
+			policy:   retry.RetryOnTemporaryPolicy,
+			err:      errors.New("external error"),
+			expRetry: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			res := test.policy(context.TODO(), test.err)
+			assert.Equal(test.expRetry, res)
+		})
+	}
+}
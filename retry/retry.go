@@ -2,23 +2,55 @@ package retry
 
 import (
 	"context"
-	"math"
-	"math/rand"
 	"time"
 
 	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/deadline"
+	"github.com/slok/goresilience/errors"
 	"github.com/slok/goresilience/metrics"
 )
 
 // Config is the configuration used for the retry Runner.
 type Config struct {
-	// WaitBase is the base unit duration to wait on the retries.
+	// WaitBase is the base unit duration to wait on the retries. It will be
+	// ignored if a Backoff is set.
 	WaitBase time.Duration
 	// Backoff enables exponential backoff on the retry (also disables jitter).
+	// It will be ignored if a Backoff is set.
 	DisableBackoff bool
+	// Backoff is the strategy used to know the duration to wait before each retry.
+	// By default it will use an exponential backoff with full jitter based on the
+	// WaitBase, or a constant backoff of WaitBase if DisableBackoff is enabled.
+	Backoff Backoff
+	// MaxWait is the maximum duration to wait before a retry, if the backoff
+	// returns a greater duration it will be capped. By default there is no limit.
+	MaxWait time.Duration
 	// Times is the number of times that will be retried in case of error
 	// before returning the error itself.
 	Times int
+	// RetryablePolicy decides if an execution error should be retried or not.
+	// By default every error will be retried.
+	RetryablePolicy RetryablePolicy
+	// MaxElapsedTime is the maximum time that all the executions with its retries
+	// can last, if the next retry would exceed this time, the retries will be aborted.
+	// By default there is no limit.
+	MaxElapsedTime time.Duration
+	// AbortOnDeadline will abort the retries if the wait before the next retry
+	// would exceed the context deadline, instead of waiting for a retry that would
+	// be cancelled.
+	AbortOnDeadline bool
+	// Budget is the retry budget used to limit the retries, it can be shared
+	// between multiple retry runners. By default there is no budget.
+	Budget *Budget
+	// MaxRetryAfter is the maximum duration to wait before a retry when the error
+	// has a retry after hint (check RetryAfterError), greater durations will be capped.
+	// By default there is no limit.
+	MaxRetryAfter time.Duration
+	// ReturnAllErrors will return an ExhaustedError with the errors of all the
+	// attempts when all of them fail, instead of the error of the last attempt.
+	// If the retries end early (a not retryable error or an abort) the errors of
+	// the attempts made are returned too (on aborts as the AbortedError error).
+	ReturnAllErrors bool
 }
 
 func (c *Config) defaults() {
@@ -30,6 +62,19 @@ func (c *Config) defaults() {
 	if c.Times <= 0 {
 		c.Times = 3
 	}
+
+	if c.Backoff == nil {
+		if c.DisableBackoff {
+			c.Backoff = NewConstantBackoff(c.WaitBase)
+		} else {
+			// The first retry waits up to 2 times the base.
+			c.Backoff = NewFullJitterBackoff(2 * c.WaitBase)
+		}
+	}
+
+	if c.RetryablePolicy == nil {
+		c.RetryablePolicy = RetryAllPolicy
+	}
 }
 
 // New returns a new retry ready executor, the execution will be retried the number
@@ -46,11 +91,20 @@ func NewMiddleware(cfg Config) goresilience.Middleware {
 	return func(next goresilience.Runner) goresilience.Runner {
 		next = goresilience.SanitizeRunner(next)
 
-		// Use the algorithms for jitter and backoff.
-		// https://aws.amazon.com/es/blogs/architecture/exponential-backoff-and-jitter/
 		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
 			var err error
+			var waitDuration time.Duration
+			var attemptErrs []AttemptError
 			metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+			start := time.Now()
+
+			// attemptsErr returns the error of the attempts made.
+			attemptsErr := func() error {
+				if cfg.ReturnAllErrors {
+					return &ExhaustedError{Attempts: attemptErrs}
+				}
+				return err
+			}
 
 			// Start the attemps. (it's 1 + the number of retries.)
 			for i := 0; i <= cfg.Times; i++ {
@@ -59,32 +113,105 @@ func NewMiddleware(cfg Config) goresilience.Middleware {
 					metricsRecorder.IncRetry()
 				}
 
-				err = next.Run(ctx, f)
+				attemptCtx := SetAttemptOnContext(ctx, Attempt{
+					Number:  i + 1,
+					Total:   cfg.Times + 1,
+					PrevErr: err,
+				})
+				attemptStart := time.Now()
+				err = next.Run(attemptCtx, f)
 				if err == nil {
+					if cfg.Budget != nil {
+						metricsRecorder.SetRetryBudgetTokens(cfg.Budget.Deposit())
+					}
 					return nil
 				}
 
-				// We need to sleep before making a retry.
-				waitDuration := cfg.WaitBase
-
-				// Apply Backoff.
-				// The backoff is calculated exponentially based on a base time
-				// and the attemp of the retry.
-				if !cfg.DisableBackoff {
-					exp := math.Exp2(float64(i + 1))
-					waitDuration = time.Duration(float64(cfg.WaitBase) * exp)
-					// Round to millisecs.
-					waitDuration = waitDuration.Round(time.Millisecond)
-
-					// Apply "full jitter".
-					random := rand.New(rand.NewSource(time.Now().UnixNano()))
-					waitDuration = time.Duration(float64(waitDuration) * random.Float64())
+				if cfg.ReturnAllErrors {
+					attemptErrs = append(attemptErrs, AttemptError{
+						Err:      err,
+						Start:    attemptStart,
+						Duration: time.Since(attemptStart),
+					})
+				}
+
+				// Don't retry the errors that will not succeed.
+				if !cfg.RetryablePolicy(ctx, err) {
+					return attemptsErr()
+				}
+
+				// The error could know how much time we need to wait or if it should
+				// not be retried at all.
+				retryAfter, hasRetryAfter := retryAfterFromError(err)
+				if hasRetryAfter && retryAfter < 0 {
+					return attemptsErr()
+				}
+
+				// If this was the last attempt we don't need to wait.
+				if i == cfg.Times {
+					break
 				}
 
-				time.Sleep(waitDuration)
+				// We need to sleep before making a retry, the wait hinted
+				// by the error has priority over the backoff.
+				switch {
+				case hasRetryAfter:
+					waitDuration = retryAfter
+					if cfg.MaxRetryAfter > 0 && waitDuration > cfg.MaxRetryAfter {
+						waitDuration = cfg.MaxRetryAfter
+					}
+				default:
+					waitDuration = cfg.Backoff.Wait(i+1, waitDuration)
+					if cfg.MaxWait > 0 && waitDuration > cfg.MaxWait {
+						waitDuration = cfg.MaxWait
+					}
+				}
+
+				// Check if we are allowed to wait for the next retry.
+				if cfg.MaxElapsedTime > 0 && time.Since(start)+waitDuration > cfg.MaxElapsedTime {
+					return &AbortedError{Reason: errors.ErrRetryMaxElapsedTime, Err: attemptsErr()}
+				}
+				if deadline, ok := ctx.Deadline(); ok && cfg.AbortOnDeadline && time.Until(deadline) < waitDuration {
+					return &AbortedError{Reason: errors.ErrRetryDeadlineExceeded, Err: attemptsErr()}
+				}
+				if budget, ok := deadline.BudgetFromContext(ctx); ok {
+					// The budget left after waiting needs to be enough for the attempts left.
+					attemptBudget := (budget.Remaining() - waitDuration) / time.Duration(cfg.Times-i)
+					if !budget.Enough(attemptBudget) {
+						return &AbortedError{Reason: errors.ErrDeadlineBudgetExhausted, Err: attemptsErr()}
+					}
+				}
+
+				// Check if the budget allows us to retry.
+				if cfg.Budget != nil {
+					ok, tokens := cfg.Budget.Withdraw()
+					metricsRecorder.SetRetryBudgetTokens(tokens)
+					if !ok {
+						return &AbortedError{Reason: errors.ErrRetryBudgetExhausted, Err: attemptsErr()}
+					}
+				}
+
+				// Wait unless the context is cancelled while waiting.
+				if !wait(ctx, waitDuration) {
+					return &AbortedError{Reason: errors.ErrContextCanceled, Err: attemptsErr()}
+				}
 			}
 
-			return err
+			return attemptsErr()
 		})
 	}
 }
+
+// wait will wait the required duration, it will return false if the context
+// has been cancelled while waiting.
+func wait(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/slok/goresilience"
+	grerrors "github.com/slok/goresilience/errors"
 	"github.com/slok/goresilience/retry"
 )
 
@@ -63,6 +64,33 @@ func TestRetryResult(t *testing.T) {
 			},
 			expErr: err,
 		},
+		{
+			name: "A failing execution should not be retried if the error is not retryable.",
+			cfg: retry.Config{
+				WaitBase:        1 * time.Nanosecond,
+				DisableBackoff:  true,
+				Times:           3,
+				RetryablePolicy: func(_ context.Context, _ error) bool { return false },
+			},
+			getF: func() goresilience.Func {
+				c := &counterFailer{notFailOnAttemp: 2}
+				return c.Run
+			},
+			expErr: err,
+		},
+		{
+			name: "A failing execution should wait at most the max wait between retries.",
+			cfg: retry.Config{
+				Backoff: retry.NewConstantBackoff(1 * time.Hour),
+				MaxWait: 1 * time.Nanosecond,
+				Times:   3,
+			},
+			getF: func() goresilience.Func {
+				c := &counterFailer{notFailOnAttemp: 4}
+				return c.Run
+			},
+			expErr: nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -77,6 +105,290 @@ func TestRetryResult(t *testing.T) {
 	}
 }
 
+func TestRetryAbort(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           retry.Config
+		ctxTimeout    time.Duration
+		expExecutions int
+		expReason     error
+	}{
+		{
+			name: "A context cancelled while waiting for a retry should abort the retries.",
+			cfg: retry.Config{
+				WaitBase:       1 * time.Second,
+				DisableBackoff: true,
+				Times:          3,
+			},
+			ctxTimeout:    20 * time.Millisecond,
+			expExecutions: 1,
+			expReason:     grerrors.ErrContextCanceled,
+		},
+		{
+			name: "Retries that exceed the max elapsed time should be aborted.",
+			cfg: retry.Config{
+				WaitBase:       30 * time.Millisecond,
+				DisableBackoff: true,
+				Times:          5,
+				MaxElapsedTime: 50 * time.Millisecond,
+			},
+			expExecutions: 2,
+			expReason:     grerrors.ErrRetryMaxElapsedTime,
+		},
+		{
+			name: "Retries with a wait that exceeds the context deadline should be aborted if configured.",
+			cfg: retry.Config{
+				WaitBase:        1 * time.Second,
+				DisableBackoff:  true,
+				Times:           3,
+				AbortOnDeadline: true,
+			},
+			ctxTimeout:    500 * time.Millisecond,
+			expExecutions: 1,
+			expReason:     grerrors.ErrRetryDeadlineExceeded,
+		},
+		{
+			name: "Retries should be aborted when the budget is exhausted.",
+			cfg: retry.Config{
+				WaitBase:       1 * time.Nanosecond,
+				DisableBackoff: true,
+				Times:          3,
+				Budget:         retry.NewBudget(retry.BudgetConfig{MaxTokens: 1}),
+			},
+			expExecutions: 2,
+			expReason:     grerrors.ErrRetryBudgetExhausted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ctx := context.Background()
+			if test.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.ctxTimeout)
+				defer cancel()
+			}
+
+			c := &counterFailer{}
+			cmd := retry.New(test.cfg)
+			gotErr := cmd.Run(ctx, c.Run)
+
+			var abortErr *retry.AbortedError
+			if assert.True(errors.As(gotErr, &abortErr)) {
+				assert.Equal(test.expReason, abortErr.Reason)
+			}
+			assert.True(errors.Is(gotErr, test.expReason))
+			assert.True(errors.Is(gotErr, err))
+			assert.Equal(test.expExecutions, c.timesExecuted)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           retry.Config
+		retryErr      error
+		expExecutions int
+		expErr        bool
+	}{
+		{
+			name: "An error with retry after hint should use the hint instead of the backoff.",
+			cfg: retry.Config{
+				Backoff: retry.NewConstantBackoff(1 * time.Hour),
+				Times:   3,
+			},
+			retryErr:      retry.WithRetryAfter(err, 1*time.Nanosecond),
+			expExecutions: 3,
+		},
+		{
+			name: "An error with retry after hint should be capped by the max retry after.",
+			cfg: retry.Config{
+				Times:         3,
+				MaxRetryAfter: 1 * time.Nanosecond,
+			},
+			retryErr:      retry.WithRetryAfter(err, 1*time.Hour),
+			expExecutions: 3,
+		},
+		{
+			name: "An error with a no retry hint should not be retried.",
+			cfg: retry.Config{
+				WaitBase:       1 * time.Nanosecond,
+				DisableBackoff: true,
+				Times:          3,
+			},
+			retryErr:      fmt.Errorf("wrapped: %w", retry.NoRetry(err)),
+			expExecutions: 1,
+			expErr:        true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Don't wait forever if the hints are not used.
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			defer cancel()
+
+			executions := 0
+			cmd := retry.New(test.cfg)
+			gotErr := cmd.Run(ctx, func(_ context.Context) error {
+				executions++
+				if executions == 3 {
+					return nil
+				}
+				return test.retryErr
+			})
+
+			if test.expErr {
+				assert.True(errors.Is(gotErr, err))
+			} else {
+				assert.NoError(gotErr)
+			}
+			assert.Equal(test.expExecutions, executions)
+		})
+	}
+}
+
+func TestRetryAttempt(t *testing.T) {
+	assert := assert.New(t)
+
+	var attempts []retry.Attempt
+	cmd := retry.New(retry.Config{
+		WaitBase:       1 * time.Nanosecond,
+		DisableBackoff: true,
+		Times:          2,
+	})
+	gotErr := cmd.Run(context.TODO(), func(ctx context.Context) error {
+		attempt, ok := retry.AttemptFromContext(ctx)
+		if !ok {
+			return errors.New("missing attempt")
+		}
+		attempts = append(attempts, attempt)
+		return fmt.Errorf("attempt %d failed", attempt.Number)
+	})
+
+	if assert.Error(gotErr) {
+		assert.Equal("attempt 3 failed", gotErr.Error())
+	}
+	if assert.Len(attempts, 3) {
+		assert.Equal(retry.Attempt{Number: 1, Total: 3}, attempts[0])
+		assert.Equal(2, attempts[1].Number)
+		assert.Equal(3, attempts[1].Total)
+		assert.EqualError(attempts[1].PrevErr, "attempt 1 failed")
+		assert.Equal(3, attempts[2].Number)
+		assert.EqualError(attempts[2].PrevErr, "attempt 2 failed")
+	}
+}
+
+type statusErr struct {
+	code int
+}
+
+func (s *statusErr) Error() string { return fmt.Sprintf("status %d", s.code) }
+
+func TestRetryExhaustedError(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       retry.Config
+		errs      []error
+		expErrMsg string
+		expIs     []error
+		expCode   int
+	}{
+		{
+			name: "If all the attempts fail, it should return the errors of all the attempts.",
+			cfg: retry.Config{
+				WaitBase:        1 * time.Nanosecond,
+				DisableBackoff:  true,
+				Times:           2,
+				ReturnAllErrors: true,
+			},
+			errs:      []error{grerrors.ErrTimeout, grerrors.ErrTimeout, &statusErr{code: 500}},
+			expErrMsg: "all 3 attempts failed: timeout while executing; timeout while executing; status 500",
+			expIs:     []error{grerrors.ErrTimeout},
+			expCode:   500,
+		},
+		{
+			name: "If all the attempts fail, the errors of any of the attempts should be checked.",
+			cfg: retry.Config{
+				WaitBase:        1 * time.Nanosecond,
+				DisableBackoff:  true,
+				Times:           2,
+				ReturnAllErrors: true,
+			},
+			errs:      []error{&statusErr{code: 503}, err, grerrors.ErrTimeout},
+			expErrMsg: "all 3 attempts failed: status 503; wanted error; timeout while executing",
+			expIs:     []error{err, grerrors.ErrTimeout},
+			expCode:   503,
+		},
+		{
+			name: "If an attempt fails with a not retryable error, it should return the errors of the attempts made.",
+			cfg: retry.Config{
+				WaitBase:       1 * time.Nanosecond,
+				DisableBackoff: true,
+				Times:          5,
+				RetryablePolicy: func(_ context.Context, err error) bool {
+					return err == grerrors.ErrTimeout
+				},
+				ReturnAllErrors: true,
+			},
+			errs:      []error{grerrors.ErrTimeout, grerrors.ErrTimeout, &statusErr{code: 500}},
+			expErrMsg: "all 3 attempts failed: timeout while executing; timeout while executing; status 500",
+			expIs:     []error{grerrors.ErrTimeout},
+			expCode:   500,
+		},
+		{
+			name: "If the retries are aborted, it should return the errors of the attempts made.",
+			cfg: retry.Config{
+				WaitBase:        1 * time.Nanosecond,
+				DisableBackoff:  true,
+				Times:           5,
+				Budget:          retry.NewBudget(retry.BudgetConfig{MaxTokens: 1}),
+				ReturnAllErrors: true,
+			},
+			errs:      []error{grerrors.ErrTimeout, &statusErr{code: 500}},
+			expErrMsg: "retry budget exhausted: all 2 attempts failed: timeout while executing; status 500",
+			expIs:     []error{grerrors.ErrRetryBudgetExhausted, grerrors.ErrTimeout},
+			expCode:   500,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			i := 0
+			cmd := retry.New(test.cfg)
+			gotErr := cmd.Run(context.TODO(), func(_ context.Context) error {
+				err := test.errs[i]
+				i++
+				return err
+			})
+
+			var exhaustedErr *retry.ExhaustedError
+			if assert.True(errors.As(gotErr, &exhaustedErr)) {
+				assert.Len(exhaustedErr.Attempts, len(test.errs))
+				for i, a := range exhaustedErr.Attempts {
+					assert.Equal(test.errs[i], a.Err)
+					assert.False(a.Start.IsZero())
+				}
+			}
+			assert.EqualError(gotErr, test.expErrMsg)
+			for _, expErr := range test.expIs {
+				assert.True(errors.Is(gotErr, expErr))
+			}
+			var sErr *statusErr
+			if assert.True(errors.As(gotErr, &sErr)) {
+				assert.Equal(test.expCode, sErr.code)
+			}
+		})
+	}
+}
+
 var notime = time.Time{}
 
 // patternTimer will store the execution time passed
@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"errors"
+	"time"
+)
+
+// DoNotRetry is the retry after duration that signals the retry runner
+// that the error should not be retried.
+const DoNotRetry time.Duration = -1
+
+// RetryAfterError is the interface that the errors that know how much time needs
+// to be waited before retrying (e.g a 429 or 503 HTTP response with a `Retry-After`
+// header) need to implement. The retry runner will use this duration instead of
+// the one of the backoff. A negative duration (like DoNotRetry) means that the
+// error should not be retried.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+// WithRetryAfter wraps an error with the duration that should be waited before
+// retrying it.
+func WithRetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, retryAfter: d}
+}
+
+// NoRetry wraps an error so the retry runner doesn't retry it.
+func NoRetry(err error) error {
+	return WithRetryAfter(err, DoNotRetry)
+}
+
+type retryAfterError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryAfterError) Error() string             { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error             { return e.err }
+func (e *retryAfterError) RetryAfter() time.Duration { return e.retryAfter }
+
+// retryAfterFromError returns the retry after hint of the error if it has one.
+func retryAfterFromError(err error) (time.Duration, bool) {
+	var raErr RetryAfterError
+	if !errors.As(err, &raErr) {
+		return 0, false
+	}
+	return raErr.RetryAfter(), true
+}
@@ -0,0 +1,66 @@
+package timeout
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/slok/goresilience/metrics"
+)
+
+// AbandonedResultFunc is the function that will receive the result of an execution
+// that has been abandoned by the timeout runner when it finishes, e.g: for logging.
+// The context is the one of the execution (it will be done at this point).
+type AbandonedResultFunc func(ctx context.Context, err error, duration time.Duration)
+
+// abandoned tracks the executions that have been abandoned by the timeout runner
+// and are still running.
+type abandoned struct {
+	max      int64
+	onResult AbandonedResultFunc
+	running  int64
+	// mu serializes the updates of the running executions with their measurement,
+	// so the last measured value is always the latest one.
+	mu sync.Mutex
+}
+
+func newAbandoned(max int, onResult AbandonedResultFunc) *abandoned {
+	return &abandoned{
+		max:      int64(max),
+		onResult: onResult,
+	}
+}
+
+// allow returns if a new execution can be made based on the abandoned
+// executions that are still running.
+func (a *abandoned) allow() bool {
+	return a.max <= 0 || atomic.LoadInt64(&a.running) < a.max
+}
+
+// add adds a new abandoned execution.
+func (a *abandoned) add(metricsRecorder metrics.Recorder) {
+	a.update(metricsRecorder, 1)
+}
+
+// remove removes an abandoned execution that finally wasn't abandoned.
+func (a *abandoned) remove(metricsRecorder metrics.Recorder) {
+	a.update(metricsRecorder, -1)
+}
+
+// finish marks an abandoned execution as finished.
+func (a *abandoned) finish(ctx context.Context, metricsRecorder metrics.Recorder, err error, duration time.Duration) {
+	a.update(metricsRecorder, -1)
+	if a.onResult != nil {
+		a.onResult(ctx, err, duration)
+	}
+}
+
+// update updates the number of abandoned executions running and measures them.
+func (a *abandoned) update(metricsRecorder metrics.Recorder, delta int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	running := atomic.AddInt64(&a.running, delta)
+	metricsRecorder.SetTimeoutAbandonedExecutions(int(running))
+}
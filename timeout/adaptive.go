@@ -0,0 +1,148 @@
+package timeout
+
+import (
+	"context"
+	"time"
+
+	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/internal/latency"
+	"github.com/slok/goresilience/metrics"
+)
+
+// AdaptiveConfig is the configuration of the adaptive timeout.
+type AdaptiveConfig struct {
+	// Percentile is the percentile (0-100) of the observed latency of the successful
+	// executions that will be used to calculate the timeout.
+	Percentile float64
+	// Multiplier is the factor applied to the latency percentile to calculate
+	// the timeout, e.g: 2 with a percentile of 99 will timeout the executions
+	// that take more than 2 times the 99th percentile.
+	Multiplier float64
+	// MinTimeout is the minimum timeout that can be calculated.
+	MinTimeout time.Duration
+	// MaxTimeout is the maximum timeout that can be calculated.
+	MaxTimeout time.Duration
+	// MinimumSamples is the number of latency samples required to start calculating
+	// the timeout, until then the WarmupTimeout will be used.
+	MinimumSamples int
+	// WarmupTimeout is the timeout used while there aren't enough latency samples.
+	// By default it will be the MaxTimeout.
+	WarmupTimeout time.Duration
+	// MaxSamples is the number of latency samples that will be used to calculate
+	// the timeout, the old samples will lose weight progressively. It will be at
+	// least 2 times the MinimumSamples, otherwise when the old samples lose weight
+	// there would not be enough samples and the WarmupTimeout would be used again.
+	MaxSamples int
+	// MaxAbandoned is the maximum number of executions abandoned on a timeout that
+	// can be running at the same time (e.g: functions that ignore the context), when
+	// reached, the new executions will be rejected. By default there is no limit.
+	MaxAbandoned int
+	// OnAbandonedResult will be called with the result of the abandoned executions
+	// when they finish.
+	OnAbandonedResult AbandonedResultFunc
+}
+
+func (c *AdaptiveConfig) defaults() {
+	if c.Percentile <= 0 || c.Percentile > 100 {
+		c.Percentile = 99
+	}
+
+	if c.Multiplier <= 0 {
+		c.Multiplier = 2
+	}
+
+	if c.MinTimeout <= 0 {
+		c.MinTimeout = 10 * time.Millisecond
+	}
+
+	if c.MaxTimeout <= 0 {
+		c.MaxTimeout = 10 * time.Second
+	}
+
+	if c.MaxTimeout < c.MinTimeout {
+		c.MaxTimeout = c.MinTimeout
+	}
+
+	if c.MinimumSamples <= 0 {
+		c.MinimumSamples = 100
+	}
+
+	if c.WarmupTimeout <= 0 {
+		c.WarmupTimeout = c.MaxTimeout
+	}
+
+	if c.MaxSamples <= 0 {
+		c.MaxSamples = 1000
+	}
+
+	if c.MaxSamples < 2*c.MinimumSamples {
+		c.MaxSamples = 2 * c.MinimumSamples
+	}
+}
+
+// NewAdaptive will wrap a execution unit that will cut the execution of
+// a runner when some time passes using the context, the timeout will be
+// calculated from the observed latency of the successful executions.
+func NewAdaptive(cfg AdaptiveConfig) goresilience.Runner {
+	return NewAdaptiveMiddleware(cfg)(nil)
+}
+
+// NewAdaptiveMiddleware returns a middleware that will cut the execution of
+// a runner when some time passes using the context, the timeout will be
+// calculated from the observed latency of the successful executions.
+func NewAdaptiveMiddleware(cfg AdaptiveConfig) goresilience.Middleware {
+	cfg.defaults()
+
+	return func(next goresilience.Runner) goresilience.Runner {
+		return &adaptive{
+			cfg:       cfg,
+			latencies: latency.NewHistogram(cfg.MaxSamples),
+			abandoned: newAbandoned(cfg.MaxAbandoned, cfg.OnAbandonedResult),
+			runner:    goresilience.SanitizeRunner(next),
+		}
+	}
+}
+
+type adaptive struct {
+	cfg       AdaptiveConfig
+	latencies *latency.Histogram
+	abandoned *abandoned
+	runner    goresilience.Runner
+}
+
+func (a *adaptive) Run(ctx context.Context, f goresilience.Func) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	timeout := a.timeout()
+	metricsRecorder.SetTimeoutAdaptiveTimeout(timeout)
+
+	timeout, err := budgetTimeout(ctx, timeout)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = run(ctx, a.runner, f, timeout, a.abandoned)
+	if err == nil {
+		a.latencies.Observe(time.Since(start))
+	}
+
+	return err
+}
+
+// timeout returns the timeout based on the observed latencies.
+func (a *adaptive) timeout() time.Duration {
+	if a.latencies.Samples() < a.cfg.MinimumSamples {
+		return a.cfg.WarmupTimeout
+	}
+
+	timeout := time.Duration(float64(a.latencies.Percentile(a.cfg.Percentile)) * a.cfg.Multiplier)
+	switch {
+	case timeout < a.cfg.MinTimeout:
+		return a.cfg.MinTimeout
+	case timeout > a.cfg.MaxTimeout:
+		return a.cfg.MaxTimeout
+	}
+
+	return timeout
+}
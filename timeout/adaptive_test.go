@@ -0,0 +1,106 @@
+package timeout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/timeout"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          timeout.AdaptiveConfig
+		warmupExecs  int
+		warmupDur    time.Duration
+		execDuration time.Duration
+		expErr       error
+	}{
+		{
+			name: "While there are not enough samples, the warmup timeout should be used.",
+			cfg: timeout.AdaptiveConfig{
+				MinimumSamples: 10,
+				WarmupTimeout:  500 * time.Millisecond,
+				MinTimeout:     1 * time.Millisecond,
+			},
+			warmupExecs:  9,
+			execDuration: 50 * time.Millisecond,
+			expErr:       nil,
+		},
+		{
+			name: "When there are enough samples, the timeout should be based on the latency percentile.",
+			cfg: timeout.AdaptiveConfig{
+				MinimumSamples: 10,
+				WarmupTimeout:  500 * time.Millisecond,
+				MinTimeout:     1 * time.Millisecond,
+			},
+			warmupExecs:  10,
+			execDuration: 300 * time.Millisecond,
+			expErr:       grerrors.ErrTimeout,
+		},
+		{
+			name: "When the old samples lose weight, there should be enough samples to not use the warmup timeout.",
+			cfg: timeout.AdaptiveConfig{
+				MinimumSamples: 10,
+				MaxSamples:     12,
+				WarmupTimeout:  500 * time.Millisecond,
+				MinTimeout:     1 * time.Millisecond,
+			},
+			warmupExecs:  31,
+			execDuration: 300 * time.Millisecond,
+			expErr:       grerrors.ErrTimeout,
+		},
+		{
+			name: "The calculated timeout should not be less than the min timeout.",
+			cfg: timeout.AdaptiveConfig{
+				MinimumSamples: 10,
+				WarmupTimeout:  500 * time.Millisecond,
+				MinTimeout:     200 * time.Millisecond,
+			},
+			warmupExecs:  10,
+			execDuration: 50 * time.Millisecond,
+			expErr:       nil,
+		},
+		{
+			name: "The calculated timeout should not be greater than the max timeout.",
+			cfg: timeout.AdaptiveConfig{
+				MinimumSamples: 10,
+				Multiplier:     100,
+				WarmupTimeout:  500 * time.Millisecond,
+				MinTimeout:     1 * time.Millisecond,
+				MaxTimeout:     20 * time.Millisecond,
+			},
+			warmupExecs:  10,
+			warmupDur:    5 * time.Millisecond,
+			execDuration: 50 * time.Millisecond,
+			expErr:       grerrors.ErrTimeout,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cmd := timeout.NewAdaptive(test.cfg)
+			for i := 0; i < test.warmupExecs; i++ {
+				err := cmd.Run(context.TODO(), func(_ context.Context) error {
+					time.Sleep(test.warmupDur)
+					return nil
+				})
+				assert.NoError(err)
+			}
+
+			execDuration := test.execDuration
+			err := cmd.Run(context.TODO(), func(_ context.Context) error {
+				time.Sleep(execDuration)
+				return nil
+			})
+
+			assert.Equal(test.expErr, err)
+		})
+	}
+}
@@ -2,11 +2,14 @@ package timeout
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/slok/goresilience"
+	"github.com/slok/goresilience/deadline"
 	"github.com/slok/goresilience/errors"
 	"github.com/slok/goresilience/metrics"
+	"github.com/slok/goresilience/retry"
 )
 
 const (
@@ -19,7 +22,16 @@ type Config struct {
 	Timeout time.Duration
 	// Cancel decides if a context is canceled on a timeouted execution.
 	// This is useful to stop the middleware chain on timeout.
+	//
+	// Deprecated: The context is always canceled when the runner returns.
 	Cancel bool
+	// MaxAbandoned is the maximum number of executions abandoned on a timeout that
+	// can be running at the same time (e.g: functions that ignore the context), when
+	// reached, the new executions will be rejected. By default there is no limit.
+	MaxAbandoned int
+	// OnAbandonedResult will be called with the result of the abandoned executions
+	// when they finish.
+	OnAbandonedResult AbandonedResultFunc
 }
 
 func (c *Config) defaults() {
@@ -28,6 +40,13 @@ func (c *Config) defaults() {
 	}
 }
 
+// The states of an execution.
+const (
+	stateRunning int32 = iota
+	stateFinished
+	stateAbandoned
+)
+
 // result is a internal type used to send circuit breaker results
 // using channels.
 type result struct {
@@ -50,31 +69,97 @@ func NewMiddleware(cfg Config) goresilience.Middleware {
 
 	return func(next goresilience.Runner) goresilience.Runner {
 		next = goresilience.SanitizeRunner(next)
+		ab := newAbandoned(cfg.MaxAbandoned, cfg.OnAbandonedResult)
 		return goresilience.RunnerFunc(func(ctx context.Context, f goresilience.Func) error {
-			metricsRecorder, _ := metrics.RecorderFromContext(ctx)
-
-			// Set a timeout to the command using the context.
-			ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
-			if cfg.Cancel {
-				defer cancel()
-			}
-
-			// Run the command
-			errc := make(chan error, 1)
-			go func() {
-				errc <- next.Run(ctx, f)
-			}()
-
-			// Wait until the deadline has been reached or we have a result.
-			select {
-			// Finished correctly.
-			case err := <-errc:
+			timeout, err := budgetTimeout(ctx, cfg.Timeout)
+			if err != nil {
 				return err
-			// Timeout.
-			case <-ctx.Done():
-				metricsRecorder.IncTimeout()
-				return errors.ErrTimeout
 			}
+
+			return run(ctx, next, f, timeout, ab)
 		})
 	}
 }
+
+// budgetTimeout returns the timeout based on the deadline budget of the context (if any),
+// the budget will be split between the attempts left of the retry runner (if any). The hedged
+// executions don't split the budget because they are concurrent, not one after another.
+func budgetTimeout(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	budget, ok := deadline.BudgetFromContext(ctx)
+	if !ok {
+		return timeout, nil
+	}
+
+	attemptsLeft := 1
+	if attempt, ok := retry.AttemptFromContext(ctx); ok {
+		attemptsLeft = attempt.Total - attempt.Number + 1
+	}
+
+	attemptBudget := budget.Split(attemptsLeft)
+	if !budget.Enough(attemptBudget) {
+		return 0, errors.ErrDeadlineBudgetExhausted
+	}
+
+	if attemptBudget < timeout {
+		return attemptBudget, nil
+	}
+	return timeout, nil
+}
+
+// run will execute the runner with a timeout.
+func run(ctx context.Context, next goresilience.Runner, f goresilience.Func, timeout time.Duration, ab *abandoned) error {
+	metricsRecorder, _ := metrics.RecorderFromContext(ctx)
+
+	// Don't execute if there are too many abandoned executions running.
+	if !ab.allow() {
+		return errors.ErrRejectedExecution
+	}
+
+	// Set a timeout to the command using the context, and release
+	// its resources when we return.
+	parentCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Run the command, if the execution is abandoned the same goroutine
+	// will track the late result.
+	var state int32
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		err := next.Run(ctx, f)
+		if atomic.CompareAndSwapInt32(&state, stateRunning, stateFinished) {
+			errc <- err
+			return
+		}
+		ab.finish(ctx, metricsRecorder, err, time.Since(start))
+	}()
+
+	// Wait until the deadline has been reached or we have a result.
+	select {
+	// Finished correctly.
+	case err := <-errc:
+		return err
+	// Timeout or cancellation.
+	case <-ctx.Done():
+		// Count the execution as abandoned before marking it, so the late result
+		// can't be counted as finished before being counted as abandoned.
+		ab.add(metricsRecorder)
+
+		// If the execution finished at the same time we have the result.
+		if !atomic.CompareAndSwapInt32(&state, stateRunning, stateAbandoned) {
+			ab.remove(metricsRecorder)
+			return <-errc
+		}
+
+		// If the parent context has ended (canceled or an earlier deadline)
+		// this is not our timeout.
+		if parentCtx.Err() != nil {
+			metricsRecorder.IncTimeoutContextCanceled()
+			return errors.ErrContextCanceled
+		}
+
+		metricsRecorder.IncTimeout()
+		return errors.ErrTimeout
+	}
+}
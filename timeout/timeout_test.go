@@ -3,6 +3,7 @@ package timeout_test
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 
 	"github.com/slok/goresilience"
 	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/metrics"
 	"github.com/slok/goresilience/timeout"
 )
 
@@ -17,10 +19,11 @@ func TestTimeout(t *testing.T) {
 	err := errors.New("wanted error")
 
 	tests := []struct {
-		name   string
-		cfg    timeout.Config
-		f      goresilience.Func
-		expErr error
+		name          string
+		cfg           timeout.Config
+		parentTimeout time.Duration
+		f             goresilience.Func
+		expErr        error
 	}{
 		{
 			name: "A command that has been run without timeout shouldn't return and error.",
@@ -57,12 +60,35 @@ func TestTimeout(t *testing.T) {
 			name: "A command that has been cancelled should not continue and don't let the function panic.",
 			cfg: timeout.Config{
 				Timeout: 1,
-				Cancel: true,
+				Cancel:  true,
 			},
 			f: func(ctx context.Context) error {
 				time.Sleep(1 * time.Millisecond)
 				panic("this should not happen")
-				return errors.New("this will never be returned")
+			},
+			expErr: grerrors.ErrTimeout,
+		},
+		{
+			name: "A command whose parent context ended before the timeout should return a context canceled error.",
+			cfg: timeout.Config{
+				Timeout: 1 * time.Second,
+			},
+			parentTimeout: 5 * time.Millisecond,
+			f: func(ctx context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			},
+			expErr: grerrors.ErrContextCanceled,
+		},
+		{
+			name: "A command whose parent context has a later deadline should return a timeout error.",
+			cfg: timeout.Config{
+				Timeout: 5 * time.Millisecond,
+			},
+			parentTimeout: 1 * time.Second,
+			f: func(ctx context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
 			},
 			expErr: grerrors.ErrTimeout,
 		},
@@ -72,10 +98,97 @@ func TestTimeout(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			assert := assert.New(t)
 
+			ctx := context.Background()
+			if test.parentTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.parentTimeout)
+				defer cancel()
+			}
+
 			cmd := timeout.New(test.cfg)
-			err := cmd.Run(context.TODO(), test.f)
+			err := cmd.Run(ctx, test.f)
 
 			assert.Equal(test.expErr, err)
 		})
 	}
 }
+
+func TestTimeoutAbandoned(t *testing.T) {
+	assert := assert.New(t)
+
+	lateErr := errors.New("late error")
+	release := make(chan struct{})
+	lateResults := make(chan error, 2)
+	cmd := timeout.New(timeout.Config{
+		Timeout:      5 * time.Millisecond,
+		MaxAbandoned: 2,
+		OnAbandonedResult: func(_ context.Context, err error, _ time.Duration) {
+			lateResults <- err
+		},
+	})
+	ignoreCtx := func(_ context.Context) error {
+		<-release
+		return lateErr
+	}
+
+	// Abandon executions until the max.
+	assert.Equal(grerrors.ErrTimeout, cmd.Run(context.TODO(), ignoreCtx))
+	assert.Equal(grerrors.ErrTimeout, cmd.Run(context.TODO(), ignoreCtx))
+	assert.Equal(grerrors.ErrRejectedExecution, cmd.Run(context.TODO(), ignoreCtx))
+
+	// Finish the abandoned executions.
+	close(release)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-lateResults:
+			assert.Equal(lateErr, err)
+		case <-time.After(1 * time.Second):
+			assert.FailNow("abandoned execution result not received")
+		}
+	}
+
+	// Abandoned executions finished, so it should accept executions again.
+	assert.NoError(cmd.Run(context.TODO(), func(_ context.Context) error { return nil }))
+}
+
+// abandonedRecorder records the last measured abandoned executions.
+type abandonedRecorder struct {
+	metrics.Recorder
+	mu   sync.Mutex
+	last int
+}
+
+func (a *abandonedRecorder) SetTimeoutAbandonedExecutions(executions int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.last = executions
+}
+
+func TestTimeoutAbandonedMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	rec := &abandonedRecorder{Recorder: metrics.Dummy}
+	ctx := metrics.SetRecorderOnContext(context.TODO(), rec)
+	runner := timeout.New(timeout.Config{Timeout: 1 * time.Millisecond})
+
+	// Make the executions finish around the timeout so the late results
+	// race with the abandon of the executions.
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner.Run(ctx, func(_ context.Context) error {
+				time.Sleep(1 * time.Millisecond)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	// Wait for the late results.
+	time.Sleep(20 * time.Millisecond)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	assert.Equal(0, rec.last)
+}
@@ -0,0 +1,152 @@
+package goresilience
+
+import (
+	"context"
+	"sync"
+
+	"github.com/slok/goresilience/errors"
+)
+
+// FuncOf is like Func but the function returns a typed result along
+// with the error, this way the result doesn't need to be captured by
+// the closure of the function.
+type FuncOf[T any] func(ctx context.Context) (T, error)
+
+// commandOf is the unit of execution of the typed API.
+type commandOf[T any] struct{}
+
+// Run satisfies RunnerOf interface.
+func (commandOf[T]) Run(ctx context.Context, f FuncOf[T]) (T, error) {
+	// Only execute if we reached to the execution and the context has not been cancelled.
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, errors.ErrContextCanceled
+	default:
+		return f(ctx)
+	}
+}
+
+// RunnerOf is like Runner but knows how to execute a FuncOf and return its
+// typed result.
+type RunnerOf[T any] interface {
+	// Run will run the unit of execution passed on f and return its result.
+	Run(ctx context.Context, f FuncOf[T]) (T, error)
+}
+
+// RunnerOfFunc is a helper that will satisfy RunnerOf interface by using a function.
+type RunnerOfFunc[T any] func(ctx context.Context, f FuncOf[T]) (T, error)
+
+// Run satisfies RunnerOf interface.
+func (r RunnerOfFunc[T]) Run(ctx context.Context, f FuncOf[T]) (T, error) {
+	return r(ctx, f)
+}
+
+// MiddlewareOf represents a middleware for a typed runner, it takes a runner and returns a runner.
+type MiddlewareOf[T any] func(RunnerOf[T]) RunnerOf[T]
+
+// RunnerChainOf is like RunnerChain but for the typed runners. The regular
+// middlewares can be used on the chain by adapting them with AdaptMiddleware.
+func RunnerChainOf[T any](middlewares ...MiddlewareOf[T]) RunnerOf[T] {
+	// The bottom one is is the one that knows how to execute the command.
+	var runner RunnerOf[T] = &commandOf[T]{}
+
+	// Start wrapping in reverse order.
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		runner = middlewares[i](runner)
+	}
+
+	// Return the chain.
+	return runner
+}
+
+// SanitizeRunnerOf is like SanitizeRunner but for typed runners.
+func SanitizeRunnerOf[T any](r RunnerOf[T]) RunnerOf[T] {
+	// In case of end of execution chain.
+	if r == nil {
+		return &commandOf[T]{}
+	}
+	return r
+}
+
+// AdaptMiddleware converts a regular Middleware into a typed MiddlewareOf, this
+// way all the runners of the library can be used on typed runner chains.
+//
+// The wrapped middleware is instantiated only once (so stateful runners like
+// the circuit breaker or the bulkhead keep their state between executions)
+// and the typed result is carried through the regular chain without being
+// shared between executions.
+func AdaptMiddleware[T any](m Middleware) MiddlewareOf[T] {
+	return func(next RunnerOf[T]) RunnerOf[T] {
+		next = SanitizeRunnerOf(next)
+		runner := m(nil)
+
+		return RunnerOfFunc[T](func(ctx context.Context, f FuncOf[T]) (T, error) {
+			return runOf(ctx, runner, func(ctx context.Context) (T, error) {
+				return next.Run(ctx, f)
+			})
+		})
+	}
+}
+
+// AdaptRunner converts a regular Runner (or a RunnerChain) into a typed RunnerOf.
+func AdaptRunner[T any](r Runner) RunnerOf[T] {
+	r = SanitizeRunner(r)
+	return RunnerOfFunc[T](func(ctx context.Context, f FuncOf[T]) (T, error) {
+		return runOf(ctx, r, f)
+	})
+}
+
+// runOf will execute a typed Func using a regular runner and return the result.
+func runOf[T any](ctx context.Context, r Runner, f FuncOf[T]) (T, error) {
+	res := &result[T]{}
+	err := r.Run(ctx, func(ctx context.Context) error {
+		v, err := f(ctx)
+		if err == nil {
+			res.set(ctx, v)
+		}
+		return err
+	})
+
+	return res.get(err)
+}
+
+// result stores the result of a typed execution. It's safe to be used
+// concurrently because the runners could abandon executions that are still
+// running (e.g timeout) and these could set the result after the runner
+// has already returned.
+type result[T any] struct {
+	value T
+	ok    bool
+	mu    sync.Mutex
+}
+
+// set will set the result only if the context of the execution has not ended,
+// this way the abandoned executions (e.g timeout) finishing late can't replace
+// the result of the execution the runner returned, the latest successful
+// execution wins.
+func (r *result[T]) set(ctx context.Context, v T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	r.value = v
+	r.ok = true
+}
+
+// get returns the result of the execution based on the error of the execution,
+// if the execution failed it will return the zero value.
+func (r *result[T]) get(err error) (T, error) {
+	var zero T
+	if err != nil {
+		return zero, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.ok {
+		return zero, nil
+	}
+	return r.value, nil
+}
@@ -0,0 +1,118 @@
+package goresilience_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/goresilience"
+	grerrors "github.com/slok/goresilience/errors"
+	"github.com/slok/goresilience/retry"
+	"github.com/slok/goresilience/timeout"
+)
+
+func TestRunnerChainOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		runner    func() goresilience.RunnerOf[string]
+		getF      func() goresilience.FuncOf[string]
+		expResult string
+		expErr    error
+	}{
+		{
+			name: "A typed runner without middlewares should return the result of the Func.",
+			runner: func() goresilience.RunnerOf[string] {
+				return goresilience.RunnerChainOf[string]()
+			},
+			getF: func() goresilience.FuncOf[string] {
+				return func(_ context.Context) (string, error) { return "ok", nil }
+			},
+			expResult: "ok",
+		},
+		{
+			name: "A typed runner with adapted middlewares should return the result of the Func.",
+			runner: func() goresilience.RunnerOf[string] {
+				return goresilience.RunnerChainOf(
+					goresilience.AdaptMiddleware[string](retry.NewMiddleware(retry.Config{
+						WaitBase:       1 * time.Nanosecond,
+						DisableBackoff: true,
+					})),
+					goresilience.AdaptMiddleware[string](timeout.NewMiddleware(timeout.Config{})),
+				)
+			},
+			getF: func() goresilience.FuncOf[string] {
+				calls := 0
+				return func(_ context.Context) (string, error) {
+					calls++
+					if calls < 3 {
+						return "wrong", errors.New("wanted error")
+					}
+					return "ok", nil
+				}
+			},
+			expResult: "ok",
+		},
+		{
+			name: "A typed runner that fails should return the zero value.",
+			runner: func() goresilience.RunnerOf[string] {
+				return goresilience.AdaptRunner[string](timeout.New(timeout.Config{Timeout: 1 * time.Millisecond}))
+			},
+			getF: func() goresilience.FuncOf[string] {
+				return func(_ context.Context) (string, error) {
+					time.Sleep(20 * time.Millisecond)
+					return "late", nil
+				}
+			},
+			expErr: grerrors.ErrTimeout,
+		},
+		{
+			name: "A typed runner should not return the result of an abandoned execution that finished late.",
+			runner: func() goresilience.RunnerOf[string] {
+				return goresilience.AdaptRunner[string](goresilience.RunnerChain(
+					retry.NewMiddleware(retry.Config{
+						WaitBase:       1 * time.Nanosecond,
+						DisableBackoff: true,
+					}),
+					timeout.NewMiddleware(timeout.Config{Timeout: 100 * time.Millisecond}),
+				))
+			},
+			getF: func() goresilience.FuncOf[string] {
+				var mu sync.Mutex
+				calls := 0
+				abandonedFinished := make(chan struct{})
+				return func(_ context.Context) (string, error) {
+					mu.Lock()
+					calls++
+					call := calls
+					mu.Unlock()
+
+					// The first attempt is abandoned and finishes while the second one is running.
+					if call == 1 {
+						time.Sleep(150 * time.Millisecond)
+						close(abandonedFinished)
+						return "late-abandoned", nil
+					}
+					<-abandonedFinished
+					time.Sleep(10 * time.Millisecond)
+					return "ok", nil
+				}
+			},
+			expResult: "ok",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			res, err := test.runner().Run(context.TODO(), test.getF())
+
+			assert.Equal(test.expErr, err)
+			assert.Equal(test.expResult, res)
+		})
+	}
+}